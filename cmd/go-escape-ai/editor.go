@@ -0,0 +1,967 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/tahcohcat/go-escape-ai/game"
+)
+
+// editor is a menu-driven scenario editor. Every change is validated straight
+// away, and the scenario can be playtested from any room before saving.
+type editor struct {
+	scenario *game.Scenario
+	title    string
+	save     func(*game.Scenario) error
+	dirty    bool
+}
+
+// field is one editable value of a scenario element, shown and entered as text.
+type field struct {
+	name string
+	help string
+	get  func() string
+	set  func(string) error
+	open func() // used instead of set for values with their own menu, like an NPC's topics
+}
+
+func runEditor(scenario *game.Scenario, title string, save func(*game.Scenario) error) {
+	e := &editor{scenario: scenario, title: title, save: save}
+
+	for {
+		s := e.scenario
+		fmt.Println()
+		fmt.Printf("✏️  Editing %s\n", e.title)
+		if problems := s.Validate(); len(problems) == 0 {
+			fmt.Println("✅ No problems found")
+		} else {
+			fmt.Printf("⚠️  %d problem(s) - press v to see them\n", len(problems))
+		}
+		fmt.Println()
+		fmt.Println("  1. Details")
+		fmt.Printf("  2. Rooms (%d)\n", len(s.Rooms))
+		fmt.Printf("  3. Items (%d)\n", len(s.Items))
+		fmt.Printf("  4. Puzzles (%d)\n", len(s.Puzzles))
+		fmt.Printf("  5. Actions (%d)\n", len(s.Actions))
+		fmt.Printf("  6. Room hints (%d)\n", len(s.Hints))
+		fmt.Printf("  7. Progressive hints (%d)\n", len(s.ProgressiveHints))
+		fmt.Printf("  8. NPCs (%d)\n", len(s.NPCs))
+		fmt.Printf("  9. Timer (%s)\n", timerSummary(s.Timer))
+		fmt.Printf("  10. Fail conditions (%d)\n", len(s.FailConditions))
+		fmt.Printf("  11. Scoring (%s)\n", scoringSummary(s.Scoring))
+		fmt.Printf("  12. Achievements (%d)\n", len(s.Achievements))
+		fmt.Println("  v. Validate   w. Check it can be won   p. Playtest from here   s. Save   q. Quit")
+
+		choice, ok := readLine("Choose: ")
+		if !ok {
+			return
+		}
+
+		switch strings.ToLower(choice) {
+		case "1":
+			e.editFields("Details", e.detailFields())
+		case "2":
+			e.editRooms()
+		case "3":
+			e.editItems()
+		case "4":
+			e.editPuzzles()
+		case "5":
+			e.editActions()
+		case "6":
+			e.editRoomHints()
+		case "7":
+			e.editProgressiveHints()
+		case "8":
+			e.editNPCs()
+		case "9":
+			e.editTimer()
+		case "10":
+			e.editFailConditions()
+		case "11":
+			e.editScoring()
+		case "12":
+			e.editAchievements()
+		case "v":
+			e.printProblems()
+		case "w":
+			e.checkWinnable()
+		case "p":
+			e.playtest()
+		case "s":
+			e.saveScenario()
+		case "q":
+			if !e.dirty || confirm("Quit without saving your changes? [y/N]: ") {
+				return
+			}
+		}
+	}
+}
+
+func (e *editor) printProblems() {
+	problems := e.scenario.Validate()
+	if len(problems) == 0 {
+		fmt.Println("✅ No problems found")
+		return
+	}
+	for _, problem := range problems {
+		fmt.Printf("  ⚠️  %s\n", problem)
+	}
+}
+
+func (e *editor) checkWinnable() {
+	fmt.Println("🔍 Searching for a way to win...")
+	solution, err := game.Solve(e.scenario, game.DefaultSolverLimit)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	fmt.Printf("✅ It can be won in %d moves: %s\n", len(solution), strings.Join(solutionCommands(solution), ", "))
+}
+
+func (e *editor) saveScenario() {
+	if problems := e.scenario.Validate(); len(problems) > 0 {
+		e.printProblems()
+		if !confirm("Save anyway? [y/N]: ") {
+			return
+		}
+	}
+	if err := e.save(e.scenario); err != nil {
+		fmt.Printf("Error saving scenario: %v\n", err)
+		return
+	}
+	e.dirty = false
+	fmt.Println("💾 Saved")
+}
+
+// playtest runs the scenario in a throwaway engine, starting in a chosen room
+// with chosen items.
+func (e *editor) playtest() {
+	if len(e.scenario.Rooms) == 0 {
+		fmt.Println("Add a room first.")
+		return
+	}
+
+	var roomIDs []string
+	for _, room := range e.scenario.Rooms {
+		roomIDs = append(roomIDs, room.ID)
+	}
+	fmt.Printf("Rooms: %s\n", strings.Join(roomIDs, ", "))
+	roomID, _ := readLine(fmt.Sprintf("Start in room [%s]: ", roomIDs[0]))
+	if roomID == "" {
+		roomID = roomIDs[0]
+	}
+	inventory, _ := readLine("Start holding (item IDs, comma-separated): ")
+
+	engine, err := game.NewEngineAt(e.scenario, roomID, splitList(inventory))
+	if err != nil {
+		fmt.Printf("Can't start playtest: %v\n", err)
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("🧪 Playtesting - type 'exit' to return to the editor")
+	room, _ := engine.GetCurrentRoom()
+	fmt.Printf("📝 %s\n", room.Description)
+
+	for !engine.IsGameWon() && !engine.IsGameLost() {
+		input, ok := readLine("🧪 > ")
+		if !ok || strings.ToLower(input) == "exit" {
+			return
+		}
+		if input == "" {
+			continue
+		}
+
+		result, err := engine.ProcessCommand(input)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			continue
+		}
+		console.printEvents(result)
+	}
+
+	if engine.IsGameWon() {
+		fmt.Println("🎉 Escaped!")
+	} else {
+		fmt.Printf("💀 %s\n", engine.GetEndingMessage())
+	}
+	fmt.Printf("📊 %s\n", engine.GetGameStats())
+}
+
+// editFields shows an element's fields and lets the player change them one at a time.
+func (e *editor) editFields(title string, fields []field) {
+	for {
+		fmt.Println()
+		fmt.Printf("— %s —\n", title)
+		for i, f := range fields {
+			fmt.Printf("  %d. %s: %s\n", i+1, f.name, f.get())
+		}
+
+		choice, ok := readLine("Field to change (Enter to go back): ")
+		if !ok || choice == "" {
+			return
+		}
+		index, err := strconv.Atoi(choice)
+		if err != nil || index < 1 || index > len(fields) {
+			continue
+		}
+
+		f := fields[index-1]
+		if f.open != nil {
+			f.open()
+			continue
+		}
+		if f.help != "" {
+			fmt.Printf("  (%s)\n", f.help)
+		}
+		value, ok := readLine(fmt.Sprintf("New %s (Enter to keep, '-' to clear): ", f.name))
+		if !ok || value == "" {
+			continue
+		}
+		if value == "-" {
+			value = ""
+		}
+		if err := f.set(value); err != nil {
+			fmt.Printf("Invalid value: %v\n", err)
+			continue
+		}
+		e.dirty = true
+
+		for _, problem := range e.scenario.Validate() {
+			fmt.Printf("  ⚠️  %s\n", problem)
+		}
+	}
+}
+
+// editList lets the player pick an element to edit, add a new one or delete one.
+func (e *editor) editList(title, addPrompt string, count func() int, label func(i int) string, fields func(i int) []field, add func(id string), remove func(i int)) {
+	for {
+		fmt.Println()
+		fmt.Printf("— %s —\n", title)
+		for i := 0; i < count(); i++ {
+			fmt.Printf("  %d. %s\n", i+1, label(i))
+		}
+
+		choice, ok := readLine("Number to edit, 'a' to add, 'd <number>' to delete (Enter to go back): ")
+		if !ok || choice == "" {
+			return
+		}
+
+		switch {
+		case choice == "a":
+			id, _ := readLine(addPrompt)
+			if id == "" {
+				continue
+			}
+			add(id)
+			e.dirty = true
+			e.editFields(label(count()-1), fields(count()-1))
+		case strings.HasPrefix(choice, "d "):
+			index, err := strconv.Atoi(strings.TrimSpace(choice[2:]))
+			if err != nil || index < 1 || index > count() {
+				continue
+			}
+			if confirm(fmt.Sprintf("Delete %s? [y/N]: ", label(index-1))) {
+				remove(index - 1)
+				e.dirty = true
+			}
+		default:
+			index, err := strconv.Atoi(choice)
+			if err != nil || index < 1 || index > count() {
+				continue
+			}
+			e.editFields(label(index-1), fields(index-1))
+		}
+	}
+}
+
+func (e *editor) detailFields() []field {
+	s := e.scenario
+	return []field{
+		stringField("theme", &s.Theme),
+		stringField("setting", &s.Setting),
+		stringField("backstory", &s.BackStory),
+		stringField("win condition", &s.WinCondition),
+		stringField("difficulty", &s.Difficulty),
+	}
+}
+
+func (e *editor) editRooms() {
+	s := e.scenario
+	e.editList("Rooms", "New room ID: ",
+		func() int { return len(s.Rooms) },
+		func(i int) string { return fmt.Sprintf("%s (%s)", s.Rooms[i].Name, s.Rooms[i].ID) },
+		func(i int) []field {
+			room := &s.Rooms[i]
+			return []field{
+				stringField("id", &room.ID),
+				stringField("name", &room.Name),
+				stringField("description", &room.Description),
+				listField("items", &room.Items),
+				listField("puzzles", &room.Puzzles),
+				listField("exits", &room.Exits),
+				listField("npcs", &room.NPCs),
+				boolField("locked", &room.Locked),
+				stringField("unlock key", &room.UnlockKey),
+			}
+		},
+		func(id string) { s.Rooms = append(s.Rooms, game.Room{ID: id, Name: id}) },
+		func(i int) { s.Rooms = append(s.Rooms[:i], s.Rooms[i+1:]...) },
+	)
+}
+
+func (e *editor) editItems() {
+	s := e.scenario
+	e.editList("Items", "New item ID: ",
+		func() int { return len(s.Items) },
+		func(i int) string { return fmt.Sprintf("%s (%s)", s.Items[i].Name, s.Items[i].ID) },
+		func(i int) []field {
+			item := &s.Items[i]
+			return []field{
+				stringField("id", &item.ID),
+				stringField("name", &item.Name),
+				stringField("description", &item.Description),
+				boolField("usable", &item.Usable),
+				stringField("use with", &item.UseWith),
+				boolField("hidden", &item.Hidden),
+				stringField("revealed by", &item.RevealedBy),
+			}
+		},
+		func(id string) { s.Items = append(s.Items, game.Item{ID: id, Name: id, Usable: true}) },
+		func(i int) { s.Items = append(s.Items[:i], s.Items[i+1:]...) },
+	)
+}
+
+func (e *editor) editPuzzles() {
+	s := e.scenario
+	e.editList("Puzzles", "New puzzle ID: ",
+		func() int { return len(s.Puzzles) },
+		func(i int) string { return fmt.Sprintf("%s (%s)", s.Puzzles[i].Name, s.Puzzles[i].ID) },
+		func(i int) []field {
+			puzzle := &s.Puzzles[i]
+			return []field{
+				stringField("id", &puzzle.ID),
+				stringField("name", &puzzle.Name),
+				stringField("description", &puzzle.Description),
+				{
+					name: "type",
+					help: "answer, sequence, combination, keypad, cipher, riddle or ordering",
+					get:  func() string { return puzzle.Type },
+					set:  func(value string) error { puzzle.Type = strings.ToLower(value); return nil },
+				},
+				stringField("solution", &puzzle.Solution),
+				listField("other answers", &puzzle.Answers),
+				listField("required items", &puzzle.RequiredItems),
+				stringField("reward", &puzzle.Reward),
+				cipherField(puzzle),
+				stepsField(puzzle),
+				boolField("reset on mistake", &puzzle.ResetOnMistake),
+				stringField("mistake message", &puzzle.MistakeMessage),
+			}
+		},
+		func(id string) { s.Puzzles = append(s.Puzzles, game.Puzzle{ID: id, Name: id}) },
+		func(i int) { s.Puzzles = append(s.Puzzles[:i], s.Puzzles[i+1:]...) },
+	)
+}
+
+func (e *editor) editActions() {
+	s := e.scenario
+	e.editList("Actions", "New action ID: ",
+		func() int { return len(s.Actions) },
+		func(i int) string {
+			action := s.Actions[i]
+			return fmt.Sprintf("%s (%s %s)", action.ID, action.Trigger.Type, action.Trigger.Target)
+		},
+		func(i int) []field {
+			action := &s.Actions[i]
+			return []field{
+				stringField("id", &action.ID),
+				{
+					name: "trigger",
+					help: "examine, use, use_with, take or solve",
+					get:  func() string { return action.Trigger.Type },
+					set:  func(value string) error { action.Trigger.Type = strings.ToLower(value); return nil },
+				},
+				stringField("trigger target", &action.Trigger.Target),
+				stringField("trigger with", &action.Trigger.With),
+				conditionsField(&action.Conditions),
+				effectsField(&action.Effects),
+				stringField("message", &action.Message),
+				boolField("one time only", &action.OneTimeOnly),
+			}
+		},
+		func(id string) {
+			s.Actions = append(s.Actions, game.Action{ID: id, Trigger: game.ActionTrigger{Type: "examine"}, OneTimeOnly: true})
+		},
+		func(i int) { s.Actions = append(s.Actions[:i], s.Actions[i+1:]...) },
+	)
+}
+
+func (e *editor) editRoomHints() {
+	s := e.scenario
+	if s.Hints == nil {
+		s.Hints = make(map[string]string)
+	}
+
+	var fields []field
+	for _, room := range s.Rooms {
+		roomID := room.ID
+		fields = append(fields, field{
+			name: roomID,
+			get:  func() string { return s.Hints[roomID] },
+			set: func(value string) error {
+				if value == "" {
+					delete(s.Hints, roomID)
+				} else {
+					s.Hints[roomID] = value
+				}
+				return nil
+			},
+		})
+	}
+	e.editFields("Room hints", fields)
+}
+
+func (e *editor) editProgressiveHints() {
+	s := e.scenario
+	e.editList("Progressive hints", "Room or puzzle ID the hint is for: ",
+		func() int { return len(s.ProgressiveHints) },
+		func(i int) string {
+			hint := s.ProgressiveHints[i]
+			return fmt.Sprintf("[%s] %s", hint.Context, hint.HintText)
+		},
+		func(i int) []field {
+			hint := &s.ProgressiveHints[i]
+			return []field{
+				stringField("context", &hint.Context),
+				stringField("text", &hint.HintText),
+				intField("priority", &hint.Priority),
+				triggersField(&hint.Triggers),
+			}
+		},
+		func(context string) {
+			s.ProgressiveHints = append(s.ProgressiveHints, game.ProgressiveHint{Context: context, Priority: 1})
+		},
+		func(i int) { s.ProgressiveHints = append(s.ProgressiveHints[:i], s.ProgressiveHints[i+1:]...) },
+	)
+}
+
+func (e *editor) editNPCs() {
+	s := e.scenario
+	e.editList("NPCs", "New NPC ID: ",
+		func() int { return len(s.NPCs) },
+		func(i int) string { return fmt.Sprintf("%s (%s)", s.NPCs[i].Name, s.NPCs[i].ID) },
+		func(i int) []field {
+			npc := &s.NPCs[i]
+			return []field{
+				stringField("id", &npc.ID),
+				stringField("name", &npc.Name),
+				stringField("description", &npc.Description),
+				stringField("greeting", &npc.Greeting),
+				stringField("default response", &npc.DefaultResponse),
+				{
+					name: "persona",
+					help: "set to have replies voiced by the LLM",
+					get:  func() string { return npc.Persona },
+					set:  func(value string) error { npc.Persona = value; return nil },
+				},
+				{
+					name: "topics",
+					get:  func() string { return strconv.Itoa(len(npc.Topics)) },
+					open: func() { e.editTopics(npc) },
+				},
+				{
+					name: "trades",
+					get:  func() string { return strconv.Itoa(len(npc.Trades)) },
+					open: func() { e.editTrades(npc) },
+				},
+				{
+					name: "knowledge",
+					get:  func() string { return fmt.Sprintf("%d facts", len(npc.Knowledge)) },
+					open: func() { e.editKnowledge(npc) },
+				},
+			}
+		},
+		func(id string) { s.NPCs = append(s.NPCs, game.NPC{ID: id, Name: id}) },
+		func(i int) { s.NPCs = append(s.NPCs[:i], s.NPCs[i+1:]...) },
+	)
+}
+
+func (e *editor) editTopics(npc *game.NPC) {
+	e.editList(npc.Name+"'s topics", "New topic ID: ",
+		func() int { return len(npc.Topics) },
+		func(i int) string {
+			topic := npc.Topics[i]
+			return fmt.Sprintf("%s (%s)", topic.ID, strings.Join(topic.Keywords, ", "))
+		},
+		func(i int) []field {
+			topic := &npc.Topics[i]
+			return []field{
+				stringField("id", &topic.ID),
+				listField("keywords", &topic.Keywords),
+				conditionsField(&topic.Conditions),
+				effectsField(&topic.Effects),
+				stringField("response", &topic.Response),
+				boolField("one time only", &topic.OneTimeOnly),
+			}
+		},
+		func(id string) {
+			npc.Topics = append(npc.Topics, game.DialogueTopic{ID: id, Keywords: []string{id}})
+		},
+		func(i int) { npc.Topics = append(npc.Topics[:i], npc.Topics[i+1:]...) },
+	)
+}
+
+func (e *editor) editTrades(npc *game.NPC) {
+	e.editList(npc.Name+"'s trades", "Item ID the NPC wants: ",
+		func() int { return len(npc.Trades) },
+		func(i int) string {
+			trade := npc.Trades[i]
+			if trade.Gives == "" {
+				return "takes " + trade.Wants
+			}
+			return fmt.Sprintf("%s for %s", trade.Gives, trade.Wants)
+		},
+		func(i int) []field {
+			trade := &npc.Trades[i]
+			return []field{
+				stringField("wants", &trade.Wants),
+				stringField("gives", &trade.Gives),
+				conditionsField(&trade.Conditions),
+				effectsField(&trade.Effects),
+				stringField("response", &trade.Response),
+			}
+		},
+		func(itemID string) { npc.Trades = append(npc.Trades, game.Trade{Wants: itemID}) },
+		func(i int) { npc.Trades = append(npc.Trades[:i], npc.Trades[i+1:]...) },
+	)
+}
+
+func (e *editor) editKnowledge(npc *game.NPC) {
+	e.editList(npc.Name+"'s knowledge", "New fact ID: ",
+		func() int { return len(npc.Knowledge) },
+		func(i int) string { return fmt.Sprintf("%s: %s", npc.Knowledge[i].ID, npc.Knowledge[i].Fact) },
+		func(i int) []field {
+			fact := &npc.Knowledge[i]
+			return []field{
+				stringField("id", &fact.ID),
+				stringField("fact", &fact.Fact),
+				conditionsField(&fact.Conditions),
+			}
+		},
+		func(id string) { npc.Knowledge = append(npc.Knowledge, game.NPCFact{ID: id}) },
+		func(i int) { npc.Knowledge = append(npc.Knowledge[:i], npc.Knowledge[i+1:]...) },
+	)
+}
+
+// editTimer edits the scenario's timer, adding one if it has none. Setting the
+// limit to 0 makes the scenario untimed again.
+func (e *editor) editTimer() {
+	s := e.scenario
+	if s.Timer == nil {
+		s.Timer = &game.Timer{Mode: game.TimerModeTurns}
+	}
+	timer := s.Timer
+	limit := intField("limit", &timer.Limit)
+	limit.help = "moves for turns, seconds for real_time; 0 for no timer"
+	e.editFields("Timer", []field{
+		{
+			name: "mode",
+			help: "turns or real_time",
+			get:  func() string { return timer.Mode },
+			set:  func(value string) error { timer.Mode = strings.ToLower(value); return nil },
+		},
+		limit,
+		warningsField(&timer.Warnings),
+		stringField("expired message", &timer.ExpiredMessage),
+	})
+	if timer.Limit == 0 {
+		s.Timer = nil
+	}
+}
+
+func (e *editor) editFailConditions() {
+	s := e.scenario
+	e.editList("Fail conditions", "New fail condition ID: ",
+		func() int { return len(s.FailConditions) },
+		func(i int) string { return fmt.Sprintf("%s (%s)", s.FailConditions[i].ID, s.FailConditions[i].Type) },
+		func(i int) []field {
+			fail := &s.FailConditions[i]
+			return []field{
+				stringField("id", &fail.ID),
+				{
+					name: "type",
+					help: "timer_expired, failed_attempts or action_triggered",
+					get:  func() string { return fail.Type },
+					set:  func(value string) error { fail.Type = strings.ToLower(value); return nil },
+				},
+				{
+					name: "target",
+					help: "puzzle ID for failed_attempts (empty for any), action ID for action_triggered",
+					get:  func() string { return fail.Target },
+					set:  func(value string) error { fail.Target = value; return nil },
+				},
+				intField("threshold", &fail.Threshold),
+				stringField("message", &fail.Message),
+			}
+		},
+		func(id string) {
+			s.FailConditions = append(s.FailConditions, game.FailCondition{ID: id, Type: game.FailFailedAttempts, Threshold: 3})
+		},
+		func(i int) { s.FailConditions = append(s.FailConditions[:i], s.FailConditions[i+1:]...) },
+	)
+}
+
+// editScoring edits the scenario's point values. Values left unset use the
+// defaults, and a scenario that sets nothing is saved without scoring at all.
+func (e *editor) editScoring() {
+	s := e.scenario
+	if s.Scoring == nil {
+		s.Scoring = &game.Scoring{}
+	}
+	scoring := s.Scoring
+	defaults := game.DefaultScoring
+	e.editFields("Scoring", []field{
+		pointsField("puzzle points", &scoring.PuzzlePoints, defaults.PuzzlePoints),
+		pointsField("escape points", &scoring.EscapePoints, defaults.EscapePoints),
+		pointsField("time bonus", &scoring.TimeBonus, defaults.TimeBonus),
+		pointsField("time bonus seconds", &scoring.TimeBonusSeconds, defaults.TimeBonusSeconds),
+		pointsField("hint penalty", &scoring.HintPenalty, defaults.HintPenalty),
+		pointsField("progressive hint penalty", &scoring.ProgressiveHintPenalty, defaults.ProgressiveHintPenalty),
+		listField("secrets", &scoring.Secrets),
+		pointsField("secret points", &scoring.SecretPoints, defaults.SecretPoints),
+	})
+	if len(scoring.Secrets) == 0 {
+		scoring.Secrets = nil
+	}
+	if reflect.DeepEqual(*scoring, game.Scoring{}) {
+		s.Scoring = nil
+	}
+}
+
+func (e *editor) editAchievements() {
+	s := e.scenario
+	e.editList("Achievements", "New achievement ID: ",
+		func() int { return len(s.Achievements) },
+		func(i int) string { return fmt.Sprintf("%s (%s)", s.Achievements[i].Name, s.Achievements[i].ID) },
+		func(i int) []field {
+			achievement := &s.Achievements[i]
+			return []field{
+				stringField("id", &achievement.ID),
+				stringField("name", &achievement.Name),
+				stringField("description", &achievement.Description),
+				conditionsField(&achievement.Conditions),
+				boolField("on escape", &achievement.OnEscape),
+				limitField("max hints", &achievement.MaxHints),
+				limitField("max moves", &achievement.MaxMoves),
+				intField("points", &achievement.Points),
+			}
+		},
+		func(id string) {
+			s.Achievements = append(s.Achievements, game.Achievement{ID: id, Name: id, OnEscape: true})
+		},
+		func(i int) { s.Achievements = append(s.Achievements[:i], s.Achievements[i+1:]...) },
+	)
+}
+
+func timerSummary(timer *game.Timer) string {
+	switch {
+	case timer == nil:
+		return "none"
+	case timer.Mode == game.TimerModeTurns:
+		return fmt.Sprintf("%d moves", timer.Limit)
+	default:
+		return fmt.Sprintf("%d seconds", timer.Limit)
+	}
+}
+
+func scoringSummary(scoring *game.Scoring) string {
+	if scoring == nil {
+		return "default"
+	}
+	return "custom"
+}
+
+func stringField(name string, value *string) field {
+	return field{
+		name: name,
+		get:  func() string { return *value },
+		set:  func(text string) error { *value = text; return nil },
+	}
+}
+
+func intField(name string, value *int) field {
+	return field{
+		name: name,
+		get:  func() string { return strconv.Itoa(*value) },
+		set: func(text string) error {
+			n, err := strconv.Atoi(text)
+			if err != nil {
+				return fmt.Errorf("%q is not a number", text)
+			}
+			*value = n
+			return nil
+		},
+	}
+}
+
+// optionalIntField edits a number that can be left unset, shown as unset.
+func optionalIntField(name, unset string, value **int) field {
+	return field{
+		name: name,
+		help: "'-' to unset",
+		get: func() string {
+			if *value == nil {
+				return unset
+			}
+			return strconv.Itoa(**value)
+		},
+		set: func(text string) error {
+			if text == "" {
+				*value = nil
+				return nil
+			}
+			n, err := strconv.Atoi(text)
+			if err != nil {
+				return fmt.Errorf("%q is not a number", text)
+			}
+			*value = &n
+			return nil
+		},
+	}
+}
+
+func pointsField(name string, value **int, fallback int) field {
+	return optionalIntField(name, fmt.Sprintf("default (%d)", fallback), value)
+}
+
+func limitField(name string, value **int) field {
+	return optionalIntField(name, "no limit", value)
+}
+
+func boolField(name string, value *bool) field {
+	return field{
+		name: name,
+		help: "y or n",
+		get:  func() string { return strconv.FormatBool(*value) },
+		set: func(text string) error {
+			switch strings.ToLower(text) {
+			case "y", "yes", "true":
+				*value = true
+			case "n", "no", "false":
+				*value = false
+			default:
+				return fmt.Errorf("answer y or n")
+			}
+			return nil
+		},
+	}
+}
+
+func listField(name string, value *[]string) field {
+	return field{
+		name: name,
+		help: "comma-separated",
+		get:  func() string { return strings.Join(*value, ", ") },
+		set:  func(text string) error { *value = splitList(text); return nil },
+	}
+}
+
+func cipherField(puzzle *game.Puzzle) field {
+	return field{
+		name: "cipher",
+		help: "caesar:<shift> or substitution:<26-letter key>",
+		get: func() string {
+			switch {
+			case puzzle.Cipher == nil:
+				return ""
+			case puzzle.Cipher.Method == game.CipherCaesar:
+				return fmt.Sprintf("caesar:%d", puzzle.Cipher.Shift)
+			default:
+				return fmt.Sprintf("%s:%s", puzzle.Cipher.Method, puzzle.Cipher.Key)
+			}
+		},
+		set: func(text string) error {
+			if text == "" {
+				puzzle.Cipher = nil
+				return nil
+			}
+			method, value, _ := strings.Cut(text, ":")
+			switch strings.ToLower(method) {
+			case game.CipherCaesar:
+				shift, err := strconv.Atoi(value)
+				if err != nil {
+					return fmt.Errorf("the shift must be a number")
+				}
+				puzzle.Cipher = &game.Cipher{Method: game.CipherCaesar, Shift: shift}
+			case game.CipherSubstitution:
+				puzzle.Cipher = &game.Cipher{Method: game.CipherSubstitution, Key: strings.ToLower(value)}
+			default:
+				return fmt.Errorf("unknown cipher %q", method)
+			}
+			return nil
+		},
+	}
+}
+
+func stepsField(puzzle *game.Puzzle) field {
+	return field{
+		name: "steps",
+		help: "action => feedback; action => feedback; ...",
+		get: func() string {
+			var steps []string
+			for _, step := range puzzle.Steps {
+				steps = append(steps, fmt.Sprintf("%s => %s", step.Action, step.Feedback))
+			}
+			return strings.Join(steps, "; ")
+		},
+		set: func(text string) error {
+			var steps []game.PuzzleStep
+			for _, part := range strings.Split(text, ";") {
+				action, feedback, _ := strings.Cut(part, "=>")
+				if strings.TrimSpace(action) == "" {
+					continue
+				}
+				steps = append(steps, game.PuzzleStep{Action: strings.TrimSpace(action), Feedback: strings.TrimSpace(feedback)})
+			}
+			puzzle.Steps = steps
+			return nil
+		},
+	}
+}
+
+func warningsField(warnings *[]game.TimerWarning) field {
+	return field{
+		name: "warnings",
+		help: "remaining => message; remaining => message; ...",
+		get: func() string {
+			var parts []string
+			for _, warning := range *warnings {
+				parts = append(parts, fmt.Sprintf("%d => %s", warning.Remaining, warning.Message))
+			}
+			return strings.Join(parts, "; ")
+		},
+		set: func(text string) error {
+			var parsed []game.TimerWarning
+			for _, part := range strings.Split(text, ";") {
+				if strings.TrimSpace(part) == "" {
+					continue
+				}
+				value, message, _ := strings.Cut(part, "=>")
+				remaining, err := strconv.Atoi(strings.TrimSpace(value))
+				if err != nil {
+					return fmt.Errorf("%q should look like remaining => message", strings.TrimSpace(part))
+				}
+				parsed = append(parsed, game.TimerWarning{Remaining: remaining, Message: strings.TrimSpace(message)})
+			}
+			*warnings = parsed
+			return nil
+		},
+	}
+}
+
+func conditionsField(conditions *[]game.ActionCondition) field {
+	return field{
+		name: "conditions",
+		help: "type=value, ... with types has_item, in_room, puzzle_solved, action_performed, topic_discussed",
+		get: func() string {
+			var parts []string
+			for _, condition := range *conditions {
+				parts = append(parts, condition.Type+"="+condition.Value)
+			}
+			return strings.Join(parts, ", ")
+		},
+		set: func(text string) error {
+			var parsed []game.ActionCondition
+			for _, part := range splitList(text) {
+				kind, value, found := strings.Cut(part, "=")
+				if !found {
+					return fmt.Errorf("%q should look like type=value", part)
+				}
+				parsed = append(parsed, game.ActionCondition{Type: strings.TrimSpace(kind), Value: strings.TrimSpace(value)})
+			}
+			*conditions = parsed
+			return nil
+		},
+	}
+}
+
+func effectsField(effects *[]game.ActionEffect) field {
+	return field{
+		name: "effects",
+		help: "type=target, ... with types reveal_item, hide_item, unlock_room, add_inventory, remove_inventory",
+		get: func() string {
+			var parts []string
+			for _, effect := range *effects {
+				parts = append(parts, effect.Type+"="+effect.Target)
+			}
+			return strings.Join(parts, ", ")
+		},
+		set: func(text string) error {
+			var parsed []game.ActionEffect
+			for _, part := range splitList(text) {
+				kind, target, found := strings.Cut(part, "=")
+				if !found {
+					return fmt.Errorf("%q should look like type=target", part)
+				}
+				parsed = append(parsed, game.ActionEffect{Type: strings.TrimSpace(kind), Target: strings.TrimSpace(target)})
+			}
+			*effects = parsed
+			return nil
+		},
+	}
+}
+
+func triggersField(triggers *[]game.HintTrigger) field {
+	return field{
+		name: "triggers",
+		help: "type>=threshold, ... with types failed_attempts, time_spent (minutes), commands_tried",
+		get: func() string {
+			var parts []string
+			for _, trigger := range *triggers {
+				parts = append(parts, fmt.Sprintf("%s>=%d", trigger.Type, trigger.Threshold))
+			}
+			return strings.Join(parts, ", ")
+		},
+		set: func(text string) error {
+			var parsed []game.HintTrigger
+			for _, part := range splitList(text) {
+				kind, value, found := strings.Cut(part, ">=")
+				threshold, err := strconv.Atoi(strings.TrimSpace(value))
+				if !found || err != nil {
+					return fmt.Errorf("%q should look like type>=threshold", part)
+				}
+				parsed = append(parsed, game.HintTrigger{Type: strings.TrimSpace(kind), Threshold: threshold})
+			}
+			*triggers = parsed
+			return nil
+		},
+	}
+}
+
+func splitList(text string) []string {
+	list := []string{}
+	for _, part := range strings.Split(text, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
+
+// readLine prompts for a line of input. It returns false once input runs out.
+func readLine(prompt string) (string, bool) {
+	fmt.Print(prompt)
+	line, err := stdin.ReadString('\n')
+	line = strings.TrimSpace(line)
+	return line, err == nil || line != ""
+}
+
+func confirm(prompt string) bool {
+	answer, _ := readLine(prompt)
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/tahcohcat/go-escape-ai/game"
+)
+
+// editorScenario is a one-room escape to edit.
+func editorScenario() *game.Scenario {
+	return &game.Scenario{
+		SchemaVersion: game.SchemaVersion,
+		Theme:         "Cell",
+		Rooms:         []game.Room{{ID: "cell", Name: "Cell", Description: "A bare cell.", Puzzles: []string{"door"}}},
+		Puzzles:       []game.Puzzle{{ID: "door", Name: "Door", Description: "The door asks a riddle.", Solution: "open"}},
+	}
+}
+
+// runEditorWith runs the editor on scripted input, one answer per line, and
+// returns the scenario as saved and read back.
+func runEditorWith(t *testing.T, scenario *game.Scenario, answers ...string) *game.Scenario {
+	t.Helper()
+	stdin = bufio.NewReader(strings.NewReader(strings.Join(answers, "\n") + "\n"))
+
+	var saved *game.Scenario
+	runEditor(scenario, "test", func(s *game.Scenario) error {
+		data, err := s.Encode("yaml")
+		if err != nil {
+			return err
+		}
+		saved, err = game.DecodeScenario(data, "yaml")
+		return err
+	})
+	if saved == nil {
+		t.Fatal("the editor never saved")
+	}
+	return saved
+}
+
+func TestEditorRoundTrip(t *testing.T) {
+	saved := runEditorWith(t, editorScenario(),
+		"3", "a", "spoon", "", "", // add a spoon
+		"8", "a", "guard", // and an NPC who wants it
+		"3", "A sleepy guard.",
+		"7", "a", "door", "5", "It only opens for the polite.", "", "", // give it a topic
+		"8", "a", "spoon", "5", "Thanks, I was peckish.", "", "", // and a trade
+		"", "",
+		"2", "1", "4", "spoon", "7", "guard", "", "", // put them both in the cell
+		"9", "2", "20", "3", "5 => Hurry!", "", // a 20 move timer
+		"10", "a", "jammed", "3", "door", "5", "The door jams.", "", "",
+		"11", "5", "0", "", // free hints
+		"12", "a", "quick", "7", "10", "", "",
+		"s", "q",
+	)
+
+	if problems := saved.Validate(); len(problems) != 0 {
+		t.Errorf("saved scenario has problems: %v", problems)
+	}
+	npc, err := saved.GetNPC("guard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if npc.Description != "A sleepy guard." || len(npc.Topics) != 1 || npc.Topics[0].Response != "It only opens for the polite." {
+		t.Errorf("guard = %+v, want a description and the door topic", npc)
+	}
+	if len(npc.Trades) != 1 || npc.Trades[0].Wants != "spoon" || npc.Trades[0].Response != "Thanks, I was peckish." {
+		t.Errorf("trades = %+v, want the guard to take the spoon", npc.Trades)
+	}
+	if len(saved.Rooms[0].NPCs) != 1 || saved.Rooms[0].NPCs[0] != "guard" {
+		t.Errorf("cell NPCs = %v, want the guard", saved.Rooms[0].NPCs)
+	}
+	if timer := saved.Timer; timer == nil || timer.Mode != game.TimerModeTurns || timer.Limit != 20 ||
+		len(timer.Warnings) != 1 || timer.Warnings[0] != (game.TimerWarning{Remaining: 5, Message: "Hurry!"}) {
+		t.Errorf("timer = %+v, want 20 moves with a warning at 5", timer)
+	}
+	if len(saved.FailConditions) != 1 || saved.FailConditions[0] != (game.FailCondition{ID: "jammed", Type: game.FailFailedAttempts, Target: "door", Threshold: 3, Message: "The door jams."}) {
+		t.Errorf("fail conditions = %+v, want the door to jam", saved.FailConditions)
+	}
+	if saved.Scoring == nil || saved.Scoring.HintPenalty == nil || *saved.Scoring.HintPenalty != 0 || saved.Scoring.PuzzlePoints != nil {
+		t.Errorf("scoring = %+v, want only the hint penalty set, to 0", saved.Scoring)
+	}
+	if len(saved.Achievements) != 1 || saved.Achievements[0].MaxMoves == nil || *saved.Achievements[0].MaxMoves != 10 {
+		t.Errorf("achievements = %+v, want quick with at most 10 moves", saved.Achievements)
+	}
+}
+
+func TestEditorRemovesTimerAndScoring(t *testing.T) {
+	scenario := editorScenario()
+	limit := 10
+	scenario.Timer = &game.Timer{Mode: game.TimerModeTurns, Limit: 30}
+	scenario.Scoring = &game.Scoring{PuzzlePoints: &limit}
+
+	saved := runEditorWith(t, scenario,
+		"9", "2", "0", "", // no timer
+		"11", "1", "-", "", // default puzzle points
+		"s", "q",
+	)
+	if saved.Timer != nil || saved.Scoring != nil {
+		t.Errorf("timer = %+v, scoring = %+v, want both gone", saved.Timer, saved.Scoring)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+
+	"github.com/tahcohcat/go-escape-ai/game"
+	"github.com/tahcohcat/go-escape-ai/library"
+)
+
+// leaderboardSize is how many runs the leaderboard shows.
+const leaderboardSize = 10
+
+// localPlayer names the person at the console on the leaderboard.
+func localPlayer() string {
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "you"
+}
+
+func showLeaderboard(lib *library.Library, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: go-escape-ai leaderboard <name|file>")
+	}
+	scenario, _, err := loadScenario(lib, args[0])
+	if err != nil {
+		return err
+	}
+	console.printLeaderboard(lib, scenario)
+	return nil
+}
+
+// recordRun adds an escape to the scenario's leaderboard and says how it compares.
+func (t *terminal) recordRun(lib *library.Library, scenario *game.Scenario, player string, stats game.GameStats) {
+	run := library.NewRun(player, stats)
+	board, personalBest, err := lib.RecordRun(scenario, run)
+	if err != nil {
+		fmt.Fprintf(t.out, "Could not record your run: %v\n", err)
+		return
+	}
+
+	for i, ranked := range board.Ranked() {
+		if ranked == run {
+			fmt.Fprintf(t.out, "🏆 You placed #%d of %d on the leaderboard.\n", i+1, len(board.Runs))
+			break
+		}
+	}
+	if personalBest {
+		fmt.Fprintln(t.out, "⭐ New personal best time!")
+	}
+}
+
+func (t *terminal) printLeaderboard(lib *library.Library, scenario *game.Scenario) {
+	board, err := lib.Leaderboard(scenario)
+	if err != nil {
+		fmt.Fprintf(t.out, "Could not load the leaderboard: %v\n", err)
+		return
+	}
+	if len(board.Runs) == 0 {
+		fmt.Fprintf(t.out, "🏆 Nobody has escaped from %s yet.\n", scenario.Theme)
+		return
+	}
+
+	fmt.Fprintf(t.out, "🏆 Leaderboard for %s:\n", scenario.Theme)
+	for i, run := range board.Ranked() {
+		if i == leaderboardSize {
+			break
+		}
+		fmt.Fprintf(t.out, "  %2d. %-16s %6s  %3d moves  %d hint(s)  %5d points  %s\n",
+			i+1, run.Player, library.FormatDuration(run.Time), run.Moves, run.HintsUsed, run.Score, run.CompletedAt.Format("2006-01-02"))
+	}
+
+	if best, ok := board.PersonalBest(t.player); ok {
+		fmt.Fprintf(t.out, "⭐ Your bests: %s, %d moves, %d hint(s) over %d run(s)\n",
+			library.FormatDuration(best.BestTime), best.FewestMoves, best.FewestHints, best.Runs)
+	}
+}
@@ -1,119 +1,533 @@
+//go:generate go run . schema ../../scenario.schema.json
+
 package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/tahcohcat/go-escape-ai/game"
+	"github.com/tahcohcat/go-escape-ai/library"
 	"github.com/tahcohcat/go-escape-ai/llm"
+	"github.com/tahcohcat/go-escape-ai/server"
 )
 
 const (
 	SaveDir = ".escape-ai"
-	SaveFile = "scenario.json"
+	SaveFile = "scenario.json" // single scenario kept by older versions, imported into the library
+	SaveGameFile = "savegame.json"
+	LibraryDir = "scenarios"
+	
+	// ClassicTheme selects the hand-built scenario instead of a generated one
+	ClassicTheme = "Uncle's Study"
+)
+
+// terminal is where a game is played: the local console, or a telnet connection.
+type terminal struct {
+	in      *bufio.Reader
+	out     io.Writer
+	canSave bool // whether "save" is offered; saved games live in the player's home directory
+	player  string // name on the leaderboard
+}
+
+var (
+	stdin = bufio.NewReader(os.Stdin)
+	console = &terminal{in: stdin, out: os.Stdout, canSave: true, player: localPlayer()}
+	
+	seedFlag = flag.Int64("seed", 0, "generate the scenario offline from this seed, so the same seed gives the same rooms")
+	difficultyFlag = flag.String("difficulty", "", "difficulty of generated scenarios: easy, normal, hard or expert (asks when creating one interactively)")
 )
 
 func main() {
+	flag.Usage = printUsage
+	flag.Parse()
+	
+	lib, err := library.Open(filepath.Join(os.Getenv("HOME"), SaveDir, LibraryDir))
+	if err != nil {
+		fmt.Printf("Error opening scenario library: %v\n", err)
+		os.Exit(1)
+	}
+	importLegacyScenario(lib)
+	
+	args := flag.Args()
+	if len(args) > 0 && args[0] != "play" {
+		if err := runLibraryCommand(lib, args); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	
 	fmt.Println("🔒 Welcome to Go Escape AI 🔒")
 	fmt.Println("An AI-narrated escape room game")
 	fmt.Println()
 
 	llmClient := llm.NewClient()
 	
-	scenario, err := loadOrCreateScenario(llmClient)
+	var engine *game.Engine
+	var name string
+	if len(args) > 0 {
+		if len(args) < 2 {
+			fmt.Println("Usage: go-escape-ai play <name|file>")
+			os.Exit(1)
+		}
+		engine, name, err = playScenario(lib, args[1])
+	} else {
+		engine, name, err = resumeOrStartGame(lib, llmClient)
+	}
 	if err != nil {
 		fmt.Printf("Error setting up game: %v\n", err)
 		return
 	}
+	if engine == nil {
+		fmt.Println("Thanks for playing!")
+		return
+	}
+
+	console.play(engine, llmClient, lib, name)
+}
 
-	engine := game.NewEngine(scenario)
+// play introduces the scenario and runs the game until the player leaves.
+func (t *terminal) play(engine *game.Engine, llmClient *llm.Client, lib *library.Library, name string) {
+	scenario := engine.GetState().Scenario
+	if llmClient != nil {
+		engine.SetDialogueVoice(llmClient)
+	}
 	
-	fmt.Printf("📍 Theme: %s\n", scenario.Theme)
-	fmt.Printf("🏛️  Setting: %s\n", scenario.Setting)
-	fmt.Println()
-	fmt.Printf("📖 Backstory: %s\n", scenario.BackStory)
-	fmt.Println()
+	fmt.Fprintf(t.out, "📍 Theme: %s\n", scenario.Theme)
+	fmt.Fprintf(t.out, "🏛️  Setting: %s\n", scenario.Setting)
+	fmt.Fprintln(t.out)
+	fmt.Fprintf(t.out, "📖 Backstory: %s\n", scenario.BackStory)
+	fmt.Fprintln(t.out)
 	
-	gameLoop(engine, llmClient)
+	t.gameLoop(engine, llmClient, lib, name)
 }
 
-func loadOrCreateScenario(llmClient *llm.Client) (*game.Scenario, error) {
-	saveDir := filepath.Join(os.Getenv("HOME"), SaveDir)
-	saveFile := filepath.Join(saveDir, SaveFile)
+func resumeOrStartGame(lib *library.Library, llmClient *llm.Client) (*game.Engine, string, error) {
+	saveGameFile := filepath.Join(os.Getenv("HOME"), SaveDir, SaveGameFile)
 	
-	// Check if scenario already exists
-	if data, err := ioutil.ReadFile(saveFile); err == nil {
-		fmt.Println("Loading existing scenario...")
-		scenario, jsonErr := game.ScenarioFromJSON(data)
-		if jsonErr == nil && len(scenario.Actions) > 0 && len(scenario.ProgressiveHints) > 0 {
-			// Only use saved scenario if it has the new action system
-			return scenario, nil
+	if data, err := ioutil.ReadFile(saveGameFile); err == nil {
+		fmt.Print("Resume your saved game? [Y/n]: ")
+		answer, _ := stdin.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		
+		if answer == "" || answer == "y" || answer == "yes" {
+			engine, err := game.LoadEngine(data)
+			if err == nil {
+				engine.Resume()
+				name, _ := lib.Find(engine.GetState().Scenario)
+				return engine, name, nil
+			}
+			fmt.Printf("Could not load saved game (%v), starting a new one...\n", err)
 		}
-		fmt.Println("Existing scenario is outdated, creating new one...")
+		os.Remove(saveGameFile)
 	}
 	
-	fmt.Println("Creating new escape room scenario...")
-	fmt.Print("Enter a theme (or press Enter for random): ")
+	scenario, name, err := console.chooseScenario(lib, llmClient)
+	if err != nil || scenario == nil {
+		return nil, "", err
+	}
 	
-	reader := bufio.NewReader(os.Stdin)
-	theme, _ := reader.ReadString('\n')
-	theme = strings.TrimSpace(theme)
+	lib.RecordPlay(name)
+	return game.NewEngine(scenario), name, nil
+}
+
+// playScenario starts a scenario from the library, or straight from a scenario file.
+func playScenario(lib *library.Library, name string) (*game.Engine, string, error) {
+	scenario, name, err := loadScenario(lib, name)
+	if err != nil {
+		return nil, "", err
+	}
 	
-	if theme == "" {
-		themes := []string{
-			"Haunted Victorian Mansion",
-			"Abandoned Space Station",
-			"Ancient Egyptian Tomb",
-			"Mad Scientist's Laboratory",
-			"Pirate Ship",
-			"Time Machine Malfunction",
-			"Zombie Apocalypse Bunker",
-			"Magic Academy",
-			"Bank Heist Gone Wrong",
-			"Underwater Research Base",
-		}
-		theme = themes[int(time.Now().Unix())%len(themes)]
-		fmt.Printf("Generated theme: %s\n", theme)
-	}
-	
-	scenario, err := generateOrUseFallback(llmClient, theme)
+	// A new game replaces any saved one
+	os.Remove(filepath.Join(os.Getenv("HOME"), SaveDir, SaveGameFile))
+	if name != "" {
+		lib.RecordPlay(name)
+	}
+	return game.NewEngine(scenario), name, nil
+}
+
+// loadScenario loads a scenario by library name or file path. The returned
+// name is empty for scenarios that aren't in the library.
+func loadScenario(lib *library.Library, nameOrPath string) (*game.Scenario, string, error) {
+	if !lib.Exists(nameOrPath) {
+		if _, err := os.Stat(nameOrPath); err == nil {
+			scenario, err := game.LoadScenarioFile(nameOrPath)
+			return scenario, "", err
+		}
+	}
+	
+	scenario, err := lib.Load(nameOrPath)
+	return scenario, nameOrPath, err
+}
+
+// chooseScenario shows the library menu. It returns a nil scenario if the player quits.
+func (t *terminal) chooseScenario(lib *library.Library, llmClient *llm.Client) (*game.Scenario, string, error) {
+	entries, err := lib.List()
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	
-	// Save scenario for future use
-	if err := os.MkdirAll(saveDir, 0755); err == nil {
-		if data, err := scenario.ToJSON(); err == nil {
-			ioutil.WriteFile(saveFile, data, 0644)
-			fmt.Println("Scenario saved for future games!")
+	// A specific seed always means a freshly generated scenario
+	if len(entries) == 0 || *seedFlag != 0 {
+		return t.createScenario(lib, llmClient)
+	}
+	
+	fmt.Fprintln(t.out, "📚 Your scenarios:")
+	t.printLibrary(entries)
+	fmt.Fprintln(t.out)
+	
+	for {
+		fmt.Fprint(t.out, "Pick a number to play, [n]ew scenario, or [q]uit: ")
+		answer, readErr := t.in.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		
+		switch answer {
+		case "n", "new":
+			return t.createScenario(lib, llmClient)
+		case "q", "quit":
+			return nil, "", nil
+		}
+		
+		if index, err := strconv.Atoi(answer); err == nil && index >= 1 && index <= len(entries) {
+			name := entries[index-1].Name
+			scenario, err := lib.Load(name)
+			if err != nil {
+				return nil, "", err
+			}
+			return scenario, name, nil
+		}
+		
+		if readErr != nil {
+			return nil, "", nil
 		}
 	}
+}
+
+func (t *terminal) createScenario(lib *library.Library, llmClient *llm.Client) (*game.Scenario, string, error) {
+	fmt.Fprintln(t.out, "Creating new escape room scenario...")
+	fmt.Fprint(t.out, "Enter a theme (or press Enter for random): ")
 	
-	return scenario, nil
+	theme, _ := t.in.ReadString('\n')
+	
+	difficulty := *difficultyFlag
+	if difficulty == "" {
+		fmt.Fprint(t.out, "Difficulty - easy, normal, hard or expert (Enter for normal): ")
+		difficulty, _ = t.in.ReadString('\n')
+	}
+	level, err := game.Difficulty(strings.TrimSpace(difficulty))
+	if err != nil {
+		return nil, "", err
+	}
+	return t.generateScenario(lib, llmClient, strings.TrimSpace(theme), level)
 }
 
-func generateOrUseFallback(llmClient *llm.Client, theme string) (*game.Scenario, error) {
-	if llmClient != nil {
-		fmt.Println("Generating scenario with AI...")
-		scenario, err := llmClient.GenerateScenario(theme)
+// generateScenario creates a scenario for the theme and adds it to the library.
+func (t *terminal) generateScenario(lib *library.Library, llmClient *llm.Client, theme string, level game.DifficultyLevel) (*game.Scenario, string, error) {
+	seed := *seedFlag
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	
+	if theme == "" {
+		theme = game.Themes[int(uint64(seed)%uint64(len(game.Themes)))]
+		fmt.Fprintf(t.out, "Generated theme: %s\n", theme)
+	}
+	
+	scenario, model, err := generateOrUseFallback(t.out, llmClient, theme, seed, *seedFlag != 0, level)
+	if err != nil {
+		return nil, "", err
+	}
+	
+	name, err := lib.Add(scenario, model)
+	if err != nil {
+		fmt.Fprintf(t.out, "Could not save scenario to your library: %v\n", err)
+		return scenario, "", nil
+	}
+	fmt.Fprintf(t.out, "Scenario saved to your library as %q!\n", name)
+	
+	return scenario, name, nil
+}
+
+// generateOrUseFallback returns the scenario, tuned for the difficulty level,
+// and a description of what generated it. Offline skips the LLM and builds the
+// scenario from the seed.
+func generateOrUseFallback(out io.Writer, llmClient *llm.Client, theme string, seed int64, offline bool, level game.DifficultyLevel) (*game.Scenario, string, error) {
+	if strings.EqualFold(theme, ClassicTheme) {
+		scenario := createFallbackScenario(theme)
+		scenario.ApplyDifficulty(level)
+		if scenario.Timer != nil {
+			scenario.Timer.ExpiredMessage = "The grandfather clock strikes the hour. Somewhere below, a latch clicks shut - your uncle's treasure is sealed away for good."
+		}
+		return scenario, "built-in", nil
+	}
+	
+	if llmClient != nil && !offline {
+		fmt.Fprintln(out, "Generating scenario with AI...")
+		scenario, err := llmClient.GenerateScenario(theme, level)
 		if err == nil {
-			return scenario, nil
+			scenario.ApplyDifficulty(level)
+			return scenario, llmClient.Model(), nil
 		}
-		fmt.Printf("AI generation failed (%v), using fallback scenario...\n", err)
-	} else {
-		fmt.Println("Using fallback scenario (no API key)...")
+		fmt.Fprintf(out, "AI generation failed (%v), generating one offline...\n", err)
+	} else if llmClient == nil {
+		fmt.Fprintln(out, "Generating scenario offline (no API key)...")
 	}
 	
-	return createFallbackScenario(theme), nil
+	fmt.Fprintf(out, "🎲 Seed: %d (play it again with --seed %d)\n", seed, seed)
+	return game.GenerateScenario(theme, seed, level), fmt.Sprintf("procedural (seed %d, %s)", seed, level.Name), nil
+}
+
+// importLegacyScenario moves the single scenario kept by older versions into the library.
+func importLegacyScenario(lib *library.Library) {
+	legacyFile := filepath.Join(os.Getenv("HOME"), SaveDir, SaveFile)
+	
+	data, err := ioutil.ReadFile(legacyFile)
+	if err != nil {
+		return
+	}
+	
+	scenario, err := game.ScenarioFromJSON(data)
+	if err != nil {
+		fmt.Printf("Could not import your old scenario: %v\n", err)
+		return
+	}
+	
+	if _, found := lib.Find(scenario); !found {
+		if _, err := lib.Add(scenario, ""); err != nil {
+			return
+		}
+	}
+	os.Remove(legacyFile)
+}
+
+func runLibraryCommand(lib *library.Library, args []string) error {
+	switch args[0] {
+	case "list":
+		entries, err := lib.List()
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Println("Your library is empty. Create a scenario with: go-escape-ai generate <theme>")
+			return nil
+		}
+		console.printLibrary(entries)
+		return nil
+	case "generate":
+		theme := strings.Join(args[1:], " ")
+		level, err := game.Difficulty(*difficultyFlag)
+		if err != nil {
+			return err
+		}
+		_, _, err = console.generateScenario(lib, llm.NewClient(), theme, level)
+		return err
+	case "delete":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: go-escape-ai delete <name>")
+		}
+		if err := lib.Delete(args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted %s\n", args[1])
+		return nil
+	case "rename":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: go-escape-ai rename <name> <new name>")
+		}
+		if err := lib.Rename(args[1], args[2]); err != nil {
+			return err
+		}
+		fmt.Printf("Renamed %s to %s\n", args[1], args[2])
+		return nil
+	case "import":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: go-escape-ai import <file>")
+		}
+		name, err := lib.Import(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Imported %s as %s\n", args[1], name)
+		return nil
+	case "export":
+		if len(args) < 2 || len(args) > 3 {
+			return fmt.Errorf("usage: go-escape-ai export <name> [file]")
+		}
+		path := args[1] + ".json"
+		if len(args) == 3 {
+			path = args[2]
+		}
+		if err := lib.Export(args[1], path); err != nil {
+			return err
+		}
+		fmt.Printf("Exported %s to %s\n", args[1], path)
+		return nil
+	case "edit":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: go-escape-ai edit <name|file>")
+		}
+		scenario, name, err := loadScenario(lib, args[1])
+		if err != nil {
+			return err
+		}
+		
+		if name != "" {
+			runEditor(scenario, name, func(s *game.Scenario) error { return lib.Save(name, s) })
+		} else {
+			runEditor(scenario, args[1], func(s *game.Scenario) error { return game.SaveScenarioFile(s, args[1]) })
+		}
+		return nil
+	case "graph":
+		return exportGraph(lib, args[1:])
+	case "serve":
+		return serve(lib, args[1:])
+	case "telnet":
+		return serveTelnet(lib, args[1:])
+	case "leaderboard":
+		return showLeaderboard(lib, args[1:])
+	case "schema":
+		schema, err := game.ScenarioSchema()
+		if err != nil {
+			return err
+		}
+		if len(args) < 2 {
+			fmt.Println(string(schema))
+			return nil
+		}
+		if err := ioutil.WriteFile(args[1], append(schema, '\n'), 0644); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote scenario schema to %s\n", args[1])
+		return nil
+	default:
+		printUsage()
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func exportGraph(lib *library.Library, args []string) error {
+	flags := flag.NewFlagSet("graph", flag.ContinueOnError)
+	format := flags.String("format", game.GraphFormatDOT, "graph format: dot or mermaid")
+	path := flags.Bool("path", false, "highlight the winning path found by the solver")
+	output := flags.String("o", "", "write the graph to a file instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: go-escape-ai graph [-format dot|mermaid] [-path] [-o file] <name|file>")
+	}
+	
+	scenario, _, err := loadScenario(lib, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	
+	var solution []game.SolutionStep
+	if *path {
+		solution, err = game.Solve(scenario, game.DefaultSolverLimit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v, drawing the graph without a winning path\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Winning path (%d moves): %s\n", len(solution), strings.Join(solutionCommands(solution), ", "))
+		}
+	}
+	
+	graph, err := game.RenderGraph(scenario, *format, solution)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		fmt.Print(graph)
+		return nil
+	}
+	if err := ioutil.WriteFile(*output, []byte(graph), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s graph to %s\n", *format, *output)
+	return nil
+}
+
+func serve(lib *library.Library, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", ":8080", "address to listen on")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	
+	llmClient := llm.NewClient()
+	generate := func(theme string, seed int64, offline bool, level game.DifficultyLevel) (*game.Scenario, string, error) {
+		return generateOrUseFallback(os.Stdout, llmClient, theme, seed, offline, level)
+	}
+	
+	fmt.Printf("🔒 Serving the game API on %s\n", *addr)
+	return http.ListenAndServe(*addr, server.New(lib, llmClient, generate))
+}
+
+func solutionCommands(solution []game.SolutionStep) []string {
+	var commands []string
+	for _, step := range solution {
+		commands = append(commands, step.Command)
+	}
+	return commands
+}
+
+func (t *terminal) printLibrary(entries []library.Metadata) {
+	for i, entry := range entries {
+		details := []string{entry.Created()}
+		if entry.Difficulty != "" {
+			details = append(details, entry.Difficulty)
+		}
+		if entry.Model != "" {
+			details = append(details, entry.Model)
+		}
+		if entry.Completed {
+			details = append(details, fmt.Sprintf("✅ best %s", library.FormatDuration(entry.BestTime)))
+		} else if entry.Plays > 0 {
+			details = append(details, "not yet escaped")
+		}
+		
+		fmt.Fprintf(t.out, "  %d. %s - %s (%s)\n", i+1, entry.Name, entry.Theme, strings.Join(details, ", "))
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage: go-escape-ai [--seed n] [--difficulty level] [command]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  (none)                  Resume your saved game or pick a scenario from your library")
+	fmt.Println("  list                    List the scenarios in your library")
+	fmt.Println("  play <name|file>        Play a scenario from your library or a scenario file")
+	fmt.Println("  generate [theme]        Generate a new scenario and add it to your library")
+	fmt.Println("  delete <name>           Remove a scenario from your library")
+	fmt.Println("  rename <name> <new>     Rename a scenario")
+	fmt.Println("  import <file>           Add a JSON, YAML or TOML scenario file to your library")
+	fmt.Println("  export <name> [file]    Write a scenario out as JSON, YAML or TOML (by extension)")
+	fmt.Println("  edit <name|file>        Edit a scenario, with validation and playtesting")
+	fmt.Println("  graph [-format dot|mermaid] [-path] [-o file] <name|file>")
+	fmt.Println("                          Draw a scenario's rooms, items, actions and puzzles as a graph")
+	fmt.Println("  leaderboard <name|file> Show the fastest escapes from a scenario and your personal bests")
+	fmt.Println("  schema [file]           Print the JSON Schema for scenario files")
+	fmt.Println("  serve [-addr :8080]     Run the HTTP JSON API for web clients and bots")
+	fmt.Println("  telnet [-addr :2323]    Host games for several players over telnet")
+	fmt.Println()
+	fmt.Println("Flags:")
+	flag.CommandLine.SetOutput(os.Stdout)
+	flag.PrintDefaults()
 }
 
 func createFallbackScenario(theme string) *game.Scenario {
 	return &game.Scenario{
+		SchemaVersion: game.SchemaVersion,
 		Theme:     theme,
 		Setting:   "Your late uncle's study",
 		BackStory: "You've inherited your eccentric uncle's house. The study door slammed shut behind you and won't budge. Your uncle was known for his clever puzzles and hidden treasures. There must be a way out that reveals what he left for you.",
@@ -222,7 +636,6 @@ func createFallbackScenario(theme string) *game.Scenario {
 				Solution:      "move painting",
 				RequiredItems: []string{"letter_opener"},
 				Reward:        "You use the letter opener to carefully pry the painting aside. Behind it is a hidden panel with a compass rose carved into the wood!",
-				Solved:        false,
 			},
 			{
 				ID:            "clock_puzzle",
@@ -231,7 +644,6 @@ func createFallbackScenario(theme string) *game.Scenario {
 				Solution:      "twelve",
 				RequiredItems: []string{"compass", "loose_book"},
 				Reward:        "You realize 12 o'clock is north on a compass! You set the clock hands to 12, and hear a mechanism grinding behind the bookshelf.",
-				Solved:        false,
 			},
 			{
 				ID:            "treasure_chest",
@@ -240,7 +652,6 @@ func createFallbackScenario(theme string) *game.Scenario {
 				Solution:      "use key and compass",
 				RequiredItems: []string{"desk_drawer", "compass"},
 				Reward:        "The mysterious key fits perfectly! The compass, when placed in a depression on the lid, completes the mechanism. The chest opens to reveal maps, gold, and your uncle's final letter.",
-				Solved:        false,
 			},
 		},
 		Actions: []game.Action{
@@ -335,7 +746,7 @@ func createFallbackScenario(theme string) *game.Scenario {
 					{Type: "commands_tried", Threshold: 3},
 				},
 				HintText: "Your uncle was methodical. Start by examining the obvious things: his desk, the bookshelves, and that interesting painting.",
-				Priority: 1,
+				Priority: 5,
 			},
 			{
 				Context: "study",
@@ -343,7 +754,7 @@ func createFallbackScenario(theme string) *game.Scenario {
 					{Type: "commands_tried", Threshold: 7},
 				},
 				HintText: "That painting of the ship looks like it could be moved. The letter opener might be useful for more than just opening letters.",
-				Priority: 2,
+				Priority: 4,
 			},
 			{
 				Context: "study",
@@ -359,7 +770,7 @@ func createFallbackScenario(theme string) *game.Scenario {
 					{Type: "failed_attempts", Threshold: 2},
 				},
 				HintText: "The painting isn't flush with the wall. Try using a tool to carefully move it aside.",
-				Priority: 4,
+				Priority: 2,
 			},
 			{
 				Context: "clock_puzzle",
@@ -367,76 +778,207 @@ func createFallbackScenario(theme string) *game.Scenario {
 					{Type: "failed_attempts", Threshold: 2},
 				},
 				HintText: "On a compass, north is at 12 o'clock. Your uncle's journal talks about 'when the clock points north'.",
-				Priority: 5,
+				Priority: 1,
 			},
 		},
 	}
 }
 
-func gameLoop(engine *game.Engine, llmClient *llm.Client) {
-	reader := bufio.NewReader(os.Stdin)
+func (t *terminal) gameLoop(engine *game.Engine, llmClient *llm.Client, lib *library.Library, name string) {
+	saveGameFile := filepath.Join(os.Getenv("HOME"), SaveDir, SaveGameFile)
+	removeSave := func() {
+		if t.canSave {
+			os.Remove(saveGameFile)
+		}
+	}
 	
 	// Initial room description - show exact factual description
 	room, _ := engine.GetCurrentRoom()
-	fmt.Printf("📝 %s\n", room.Description)
-	fmt.Println()
+	fmt.Fprintf(t.out, "📝 %s\n", room.Description)
+	fmt.Fprintln(t.out)
 	
 	for {
 		if engine.IsGameWon() {
-			fmt.Println("🎉 Congratulations! You've escaped! 🎉")
-			fmt.Printf("📊 Final stats: %s\n", engine.GetGameStats())
+			fmt.Fprintln(t.out, "🎉 Congratulations! You've escaped! 🎉")
+			fmt.Fprintf(t.out, "📊 Final stats: %s\n", engine.GetGameStats())
+			t.printScore(engine.Score(), engine.Achievements())
+			removeSave()
+			if name != "" {
+				lib.RecordCompletion(name, engine.Elapsed())
+			}
+			t.recordRun(lib, engine.GetState().Scenario, t.player, engine.Stats())
 			break
 		}
 		
-		fmt.Print("> ")
-		input, _ := reader.ReadString('\n')
+		if engine.IsGameLost() {
+			fmt.Fprintf(t.out, "💀 %s 💀\n", engine.GetEndingMessage())
+			fmt.Fprintf(t.out, "📊 Final stats: %s\n", engine.GetGameStats())
+			removeSave()
+			
+			if !t.offerRestart(engine) {
+				break
+			}
+			
+			room, _ := engine.GetCurrentRoom()
+			fmt.Fprintf(t.out, "📝 %s\n", room.Description)
+			fmt.Fprintln(t.out)
+			continue
+		}
+		
+		if engine.HasTimer() {
+			fmt.Fprintf(t.out, "⏳ %s > ", engine.TimerStatus())
+		} else {
+			fmt.Fprint(t.out, "> ")
+		}
+		input, readErr := t.in.ReadString('\n')
 		input = strings.TrimSpace(input)
 		
 		if input == "" {
+			if readErr != nil {
+				break
+			}
 			continue
 		}
 		
 		if strings.ToLower(input) == "quit" || strings.ToLower(input) == "exit" {
-			fmt.Println("Thanks for playing!")
+			fmt.Fprintln(t.out, "Thanks for playing!")
 			break
 		}
 		
 		if strings.ToLower(input) == "help" {
-			printHelp()
+			t.printHelp()
 			continue
 		}
 		
 		if strings.ToLower(input) == "stats" {
-			fmt.Printf("📊 %s\n", engine.GetGameStats())
+			fmt.Fprintf(t.out, "📊 %s\n", engine.GetGameStats())
+			t.printScore(engine.Score(), engine.Achievements())
+			continue
+		}
+		
+		if strings.ToLower(input) == "leaderboard" {
+			t.printLeaderboard(lib, engine.GetState().Scenario)
 			continue
 		}
 		
+		if strings.ToLower(input) == "save" {
+			if !t.canSave {
+				fmt.Fprintln(t.out, "Saving isn't available here.")
+				continue
+			}
+			if err := saveGame(engine, saveGameFile); err != nil {
+				fmt.Fprintf(t.out, "Error saving game: %v\n", err)
+				continue
+			}
+			fmt.Fprintln(t.out, "Game saved. The clock is paused until you return.")
+			break
+		}
+		
 		// Process command
-		err := engine.ProcessCommand(input)
+		result, err := engine.ProcessCommand(input)
 		if err != nil {
-			fmt.Printf("Error: %v\n", err)
+			fmt.Fprintf(t.out, "Error: %v\n", err)
 			continue
 		}
 		
-		// Get current state for narration
-		state := engine.GetState()
 		currentRoom, _ := engine.GetCurrentRoom()
 		
 		// Always show the factual result first
-		if state.LastResult != "" {
-			fmt.Printf("📝 %s\n", state.LastResult)
+		t.printEvents(result)
+		
+		if engine.IsGameLost() {
+			fmt.Fprintln(t.out)
+			continue
 		}
 		
 		// Then add atmospheric narration if available
 		narration, err := generateNarration(llmClient, engine, currentRoom, input)
 		if err == nil && narration != "" {
-			fmt.Printf("🤖 %s\n", narration)
+			fmt.Fprintf(t.out, "🤖 %s\n", narration)
+		}
+		
+		fmt.Fprintln(t.out)
+	}
+}
+
+func (t *terminal) printEvents(result *game.CommandResult) {
+	for _, event := range result.Events {
+		if event.Message == "" {
+			continue
 		}
 		
-		fmt.Println()
+		switch event.Type {
+		case game.EventGameWon, game.EventGameLost:
+			// Endings are announced by the game loop
+		case game.EventTimerWarning:
+			fmt.Fprintf(t.out, "⏰ %s\n", event.Message)
+		case game.EventDialogue:
+			fmt.Fprintf(t.out, "💬 %s\n", event.Message)
+		case game.EventHintUnlocked:
+			fmt.Fprintf(t.out, "💡 %s\n", event.Message)
+		case game.EventSecretFound:
+			fmt.Fprintf(t.out, "✨ %s\n", event.Message)
+		case game.EventAchievementEarned:
+			fmt.Fprintf(t.out, "🏅 %s\n", event.Message)
+		default:
+			fmt.Fprintf(t.out, "📝 %s\n", event.Message)
+		}
 	}
 }
 
+func (t *terminal) printScore(score game.Score, achievements []game.Achievement) {
+	fmt.Fprintf(t.out, "🏆 Score: %s\n", score)
+	for _, achievement := range achievements {
+		fmt.Fprintf(t.out, "🏅 %s - %s\n", achievement.Name, achievement.Description)
+	}
+}
+
+func (t *terminal) offerRestart(engine *game.Engine) bool {
+	for {
+		if engine.HasCheckpoint() {
+			fmt.Fprint(t.out, "Restart from [c]heckpoint, from the [b]eginning, or [q]uit? ")
+		} else {
+			fmt.Fprint(t.out, "Restart from the [b]eginning or [q]uit? ")
+		}
+		
+		answer, err := t.in.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		
+		switch {
+		case answer == "c" && engine.HasCheckpoint():
+			if err := engine.RestartFromCheckpoint(); err != nil {
+				fmt.Fprintf(t.out, "Error restoring checkpoint: %v\n", err)
+				continue
+			}
+			fmt.Fprintln(t.out, "⏪ Restoring your last checkpoint...")
+			return true
+		case answer == "b":
+			if err := engine.Restart(); err != nil {
+				fmt.Fprintf(t.out, "Error restarting: %v\n", err)
+				continue
+			}
+			fmt.Fprintln(t.out, "⏪ Starting over from the beginning...")
+			return true
+		case answer == "q" || err != nil:
+			fmt.Fprintln(t.out, "Thanks for playing!")
+			return false
+		}
+	}
+}
+
+func saveGame(engine *game.Engine, path string) error {
+	data, err := engine.SaveState()
+	if err != nil {
+		return err
+	}
+	
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	
+	return ioutil.WriteFile(path, data, 0644)
+}
+
 func generateNarration(llmClient *llm.Client, engine *game.Engine, room *game.Room, input string) (string, error) {
 	if llmClient == nil {
 		return "", fmt.Errorf("no LLM client")
@@ -458,17 +1000,27 @@ func generateNarration(llmClient *llm.Client, engine *game.Engine, room *game.Ro
 	return llmClient.GenerateNarration(ctx)
 }
 
-func printHelp() {
-	fmt.Println("🆘 Available commands:")
-	fmt.Println("  look [item]     - Examine your surroundings or a specific item")
-	fmt.Println("  take <item>     - Pick up an item")
-	fmt.Println("  use <item>      - Use an item from your inventory")
-	fmt.Println("  go <direction>  - Move to a different room")
-	fmt.Println("  inventory       - Check what you're carrying")
-	fmt.Println("  solve <answer>  - Attempt to solve a puzzle")
-	fmt.Println("  hint            - Get a hint for the current room")
-	fmt.Println("  stats           - Show game statistics")
-	fmt.Println("  help            - Show this help message")
-	fmt.Println("  quit            - Exit the game")
-	fmt.Println()
+func (t *terminal) printHelp() {
+	fmt.Fprintln(t.out, "🆘 Available commands:")
+	fmt.Fprintln(t.out, "  look [item]     - Examine your surroundings or a specific item")
+	fmt.Fprintln(t.out, "  take <item>     - Pick up an item")
+	fmt.Fprintln(t.out, "  use <item>      - Use an item from your inventory")
+	fmt.Fprintln(t.out, "  go <direction>  - Move to a different room")
+	fmt.Fprintln(t.out, "  inventory       - Check what you're carrying")
+	fmt.Fprintln(t.out, "  solve <answer>  - Attempt to solve a puzzle")
+	fmt.Fprintln(t.out, "  enter <code> on <lock>  - Try a code on a combination lock or keypad")
+	fmt.Fprintln(t.out, "  arrange <a, b, c>       - Put things in order")
+	fmt.Fprintln(t.out, "  talk to <npc>   - Greet someone in the room")
+	fmt.Fprintln(t.out, "  ask <npc> about <topic> - Ask someone about a topic")
+	fmt.Fprintln(t.out, "  give <item> to <npc>    - Give or trade an item")
+	fmt.Fprintln(t.out, "  hint            - Get a hint for the current room")
+	fmt.Fprintln(t.out, "  undo [steps]    - Take back your last action (or several)")
+	fmt.Fprintln(t.out, "  stats           - Show game statistics")
+	fmt.Fprintln(t.out, "  leaderboard     - Show the fastest escapes from this scenario")
+	if t.canSave {
+		fmt.Fprintln(t.out, "  save            - Save your progress and quit (pauses the timer)")
+	}
+	fmt.Fprintln(t.out, "  help            - Show this help message")
+	fmt.Fprintln(t.out, "  quit            - Exit the game")
+	fmt.Fprintln(t.out)
 }
\ No newline at end of file
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tahcohcat/go-escape-ai/game"
+	"github.com/tahcohcat/go-escape-ai/library"
+	"github.com/tahcohcat/go-escape-ai/llm"
+)
+
+// teamCommands are handled by the team rather than the game, so aren't narrated.
+var teamCommands = map[string]bool{"say": true, "who": true, "team": true, "undo": true}
+
+// teamInput is a line the player typed, read while they wait on the team.
+type teamInput struct {
+	line string
+	err  error
+}
+
+// teamLoop runs a player's side of a team game until they leave or the game
+// ends, including when a teammate ends it while they're typing.
+func (t *terminal) teamLoop(team *game.Team, playerID string, llmClient *llm.Client, lib *library.Library, name string) {
+	state := team.Snapshot(playerID)
+	if room, err := state.Scenario.GetRoom(state.CurrentRoom); err == nil {
+		fmt.Fprintf(t.out, "📝 %s\n", room.Description)
+		fmt.Fprintln(t.out)
+	}
+
+	lines := make(chan teamInput)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			line, err := t.in.ReadString('\n')
+			select {
+			case lines <- teamInput{line, err}:
+			case <-stop:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		if team.IsGameWon() {
+			fmt.Fprintln(t.out, "🎉 Congratulations! Your team escaped! 🎉")
+			fmt.Fprintf(t.out, "📊 Final stats: %s\n", team.GetGameStats())
+			t.printScore(team.Score(), team.Achievements())
+			return
+		}
+		if team.IsGameLost() {
+			fmt.Fprintf(t.out, "💀 %s 💀\n", team.GetEndingMessage())
+			fmt.Fprintf(t.out, "📊 Final stats: %s\n", team.GetGameStats())
+			return
+		}
+
+		if status := team.TimerStatus(); status != "" {
+			fmt.Fprintf(t.out, "⏳ %s > ", status)
+		} else {
+			fmt.Fprint(t.out, "> ")
+		}
+		var input string
+		var readErr error
+		select {
+		case typed := <-lines:
+			input, readErr = typed.line, typed.err
+		case <-team.Done():
+			fmt.Fprintln(t.out)
+			continue
+		}
+		input = strings.TrimSpace(input)
+
+		if input == "" {
+			if readErr != nil {
+				return
+			}
+			continue
+		}
+
+		switch strings.ToLower(input) {
+		case "quit", "exit":
+			fmt.Fprintln(t.out, "Thanks for playing!")
+			return
+		case "help":
+			t.printHelp()
+			t.printTeamHelp()
+			continue
+		case "stats":
+			fmt.Fprintf(t.out, "📊 %s\n", team.GetGameStats())
+			t.printScore(team.Score(), team.Achievements())
+			continue
+		case "leaderboard":
+			t.printLeaderboard(lib, team.Scenario())
+			continue
+		}
+
+		result, err := team.ProcessCommand(playerID, input)
+		if err != nil {
+			fmt.Fprintf(t.out, "Error: %v\n", err)
+			continue
+		}
+		t.printEvents(result)
+
+		if result.Has(game.EventGameWon) {
+			if name != "" {
+				lib.RecordCompletion(name, team.Elapsed())
+			}
+			t.recordRun(lib, team.Scenario(), teamName(team), team.Stats())
+		}
+		if team.IsGameWon() || team.IsGameLost() || teamCommands[strings.Fields(strings.ToLower(input))[0]] {
+			fmt.Fprintln(t.out)
+			continue
+		}
+
+		state := team.Snapshot(playerID)
+		if room, err := state.Scenario.GetRoom(state.CurrentRoom); err == nil && llmClient != nil {
+			narration, err := llmClient.GenerateNarration(llm.NarrationContext{
+				CurrentRoom: room,
+				LastAction:  state.LastAction,
+				LastResult:  state.LastResult,
+				Inventory:   state.Inventory,
+				GameState:   state,
+				PlayerInput: input,
+			})
+			if err == nil && narration != "" {
+				fmt.Fprintf(t.out, "🤖 %s\n", narration)
+			}
+		}
+		fmt.Fprintln(t.out)
+	}
+}
+
+// teamName lists the players for the leaderboard, e.g. "Ann & Ben".
+func teamName(team *game.Team) string {
+	players := team.Finalists()
+	if players == nil {
+		players = team.Players()
+	}
+	var names []string
+	for _, player := range players {
+		names = append(names, player.Name)
+	}
+	return strings.Join(names, " & ")
+}
+
+func (t *terminal) printTeamHelp() {
+	fmt.Fprintln(t.out, "👥 In a team game:")
+	fmt.Fprintln(t.out, "  say <message>   - Talk to the rest of your team")
+	fmt.Fprintln(t.out, "  give <item> to <player> - Hand an item to a teammate in the same room")
+	fmt.Fprintln(t.out, "  who             - See where everyone is")
+	fmt.Fprintln(t.out)
+}
+
+// teamNotice describes what a teammate did.
+func teamNotice(event game.TeamEvent) string {
+	switch event.Type {
+	case game.EventSaid:
+		return fmt.Sprintf("💬 %s says: %s", event.Player, event.Message)
+	case game.EventPlayerAction, game.EventPlayerJoined, game.EventPlayerLeft, game.EventItemGiven:
+		return fmt.Sprintf("👥 %s", event.Message)
+	case game.EventGameWon:
+		return fmt.Sprintf("🎉 %s found the way out!", event.Player)
+	case game.EventTimerWarning:
+		return fmt.Sprintf("⏰ %s", event.Message)
+	case game.EventSecretFound:
+		return fmt.Sprintf("✨ %s found a secret!", event.Player)
+	case game.EventAchievementEarned:
+		return fmt.Sprintf("🏅 %s", event.Message)
+	}
+	if event.Message == "" {
+		return ""
+	}
+	return fmt.Sprintf("👥 %s: %s", event.Player, event.Message)
+}
@@ -0,0 +1,439 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tahcohcat/go-escape-ai/game"
+	"github.com/tahcohcat/go-escape-ai/library"
+	"github.com/tahcohcat/go-escape-ai/llm"
+)
+
+// Telnet protocol bytes (RFC 854, 857, 858)
+const (
+	telnetIAC  = 255
+	telnetDONT = 254
+	telnetDO   = 253
+	telnetWONT = 252
+	telnetWILL = 251
+	telnetSB   = 250
+	telnetSE   = 240
+
+	telnetEcho            = 1
+	telnetSuppressGoAhead = 3
+)
+
+const maxLineLength = 512
+
+// writeTimeout is how long a client gets to accept output before it's
+// disconnected, so a stalled connection can't hang around forever.
+const writeTimeout = 10 * time.Second
+
+// telnetServer hosts a game for every connection. Players can also host team
+// games that others join, sharing one world.
+type telnetServer struct {
+	lib *library.Library
+	llm *llm.Client
+
+	mu    sync.Mutex
+	conns map[net.Conn]*telnetLine // nil until the connection's handler has set up its line
+	teams []*hostedTeam
+	wg    sync.WaitGroup
+}
+
+type hostedTeam struct {
+	team *game.Team
+	name string // library name of the scenario
+	host string
+}
+
+func serveTelnet(lib *library.Library, args []string) error {
+	flags := flag.NewFlagSet("telnet", flag.ContinueOnError)
+	addr := flags.String("addr", ":2323", "address to listen on")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+
+	srv := &telnetServer{lib: lib, llm: llm.NewClient(), conns: make(map[net.Conn]*telnetLine)}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	fmt.Printf("🔒 Hosting escape rooms over telnet on %s (Ctrl+C to stop)\n", listener.Addr())
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
+			log.Printf("Accept failed: %v", err)
+			continue
+		}
+
+		srv.mu.Lock()
+		srv.conns[conn] = nil
+		srv.mu.Unlock()
+		srv.wg.Add(1)
+		go srv.handle(conn)
+	}
+
+	fmt.Println("Shutting down...")
+	srv.mu.Lock()
+	for conn, line := range srv.conns {
+		if line == nil {
+			conn.Close()
+			continue
+		}
+		// Said outside srv.mu, so a client that won't take it only holds up itself
+		go line.Hangup("\n\n🔒 The server is shutting down. Thanks for playing!\n")
+	}
+	srv.mu.Unlock()
+	srv.wg.Wait()
+	return nil
+}
+
+func (s *telnetServer) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	log.Printf("%s connected", conn.RemoteAddr())
+	defer log.Printf("%s disconnected", conn.RemoteAddr())
+
+	line := newTelnetLine(conn)
+	s.mu.Lock()
+	s.conns[conn] = line
+	s.mu.Unlock()
+	t := &terminal{in: bufio.NewReader(line), out: line}
+
+	fmt.Fprintln(t.out, "🔒 Welcome to Go Escape AI 🔒")
+	fmt.Fprintln(t.out, "An AI-narrated escape room game. Type 'help' once you're in for the commands.")
+	fmt.Fprintln(t.out)
+
+	fmt.Fprint(t.out, "What's your name? ")
+	player, err := t.in.ReadString('\n')
+	player = strings.TrimSpace(player)
+	if err != nil || player == "" {
+		return
+	}
+	t.player = player
+
+	for {
+		teams := s.openTeams()
+		if len(teams) > 0 {
+			fmt.Fprintln(t.out, "👥 Team games you can join:")
+			for i, hosted := range teams {
+				fmt.Fprintf(t.out, "  %d. %s, hosted by %s (%d playing)\n", i+1, hosted.team.Scenario().Theme, hosted.host, len(hosted.team.Players()))
+			}
+			fmt.Fprint(t.out, "Play [a]lone, [h]ost a team game, or pick a number to join: ")
+		} else {
+			fmt.Fprint(t.out, "Play [a]lone or [h]ost a team game? ")
+		}
+
+		answer, err := t.in.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		switch answer {
+		case "a", "alone":
+			s.playAlone(t)
+			return
+		case "h", "host":
+			scenario, name, err := t.chooseScenario(s.lib, s.llm)
+			if err != nil || scenario == nil {
+				fmt.Fprintln(t.out, "Thanks for playing!")
+				return
+			}
+			hosted := &hostedTeam{team: game.NewTeam(scenario), name: name, host: player}
+			hosted.team.SetDialogueVoice(s.llm)
+			s.mu.Lock()
+			s.teams = append(s.teams, hosted)
+			s.mu.Unlock()
+			if name != "" {
+				s.lib.RecordPlay(name)
+			}
+			s.playTeam(t, line, hosted, player)
+			return
+		}
+
+		if index, convErr := strconv.Atoi(answer); convErr == nil && index >= 1 && index <= len(teams) {
+			s.playTeam(t, line, teams[index-1], player)
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *telnetServer) playAlone(t *terminal) {
+	scenario, name, err := t.chooseScenario(s.lib, s.llm)
+	if err != nil {
+		fmt.Fprintf(t.out, "Error setting up game: %v\n", err)
+		return
+	}
+	if scenario == nil {
+		fmt.Fprintln(t.out, "Thanks for playing!")
+		return
+	}
+	if name != "" {
+		s.lib.RecordPlay(name)
+	}
+
+	t.play(game.NewEngine(scenario), s.llm, s.lib, name)
+}
+
+func (s *telnetServer) playTeam(t *terminal, line *telnetLine, hosted *hostedTeam, player string) {
+	playerID, err := hosted.team.Join(player, func(event game.TeamEvent) {
+		if notice := teamNotice(event); notice != "" {
+			line.Announce(notice)
+		}
+	})
+	if err != nil {
+		fmt.Fprintf(t.out, "Couldn't join: %v\n", err)
+		return
+	}
+	defer func() {
+		hosted.team.Leave(playerID)
+		if len(hosted.team.Players()) == 0 {
+			s.closeTeam(hosted)
+		}
+	}()
+
+	scenario := hosted.team.Scenario()
+	fmt.Fprintf(t.out, "📍 Theme: %s\n", scenario.Theme)
+	fmt.Fprintf(t.out, "🏛️  Setting: %s\n", scenario.Setting)
+	fmt.Fprintln(t.out)
+	fmt.Fprintf(t.out, "📖 Backstory: %s\n", scenario.BackStory)
+	fmt.Fprintln(t.out)
+	fmt.Fprintln(t.out, "👥 You're playing as a team. Type 'help' for the team commands.")
+
+	t.teamLoop(hosted.team, playerID, s.llm, s.lib, hosted.name)
+}
+
+// openTeams lists the team games that are still being played.
+func (s *telnetServer) openTeams() []*hostedTeam {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var open []*hostedTeam
+	for _, hosted := range s.teams {
+		if !hosted.team.IsGameWon() && !hosted.team.IsGameLost() {
+			open = append(open, hosted)
+		}
+	}
+	return open
+}
+
+func (s *telnetServer) closeTeam(hosted *hostedTeam) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, other := range s.teams {
+		if other == hosted {
+			s.teams = append(s.teams[:i], s.teams[i+1:]...)
+			return
+		}
+	}
+}
+
+// telnetLine does line editing for a telnet connection. Clients that agree to
+// let the server echo send each keystroke, which is echoed back with support
+// for backspace and Ctrl+U; line-mode clients just send whole lines. Reads
+// return complete lines ending in "\n", and writes translate "\n" to "\r\n".
+type telnetLine struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	pending []byte
+	afterCR bool
+
+	mu     sync.Mutex // guards writes and the line being typed
+	echo   bool
+	line   []byte
+	prompt string // output since the last newline, redrawn after announcements
+}
+
+func newTelnetLine(conn net.Conn) *telnetLine {
+	// Ask to do the echoing and to run in character mode
+	conn.Write([]byte{
+		telnetIAC, telnetWILL, telnetEcho,
+		telnetIAC, telnetWILL, telnetSuppressGoAhead,
+		telnetIAC, telnetDO, telnetSuppressGoAhead,
+	})
+	return &telnetLine{conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (l *telnetLine) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	text := string(p)
+	if i := strings.LastIndex(text, "\n"); i >= 0 {
+		l.prompt = text[i+1:]
+	} else {
+		l.prompt += text
+	}
+	if err := l.write(strings.ReplaceAll(text, "\n", "\r\n")); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// write sends text with a deadline, closing the connection if the client
+// doesn't take it in time. The caller must hold l.mu.
+func (l *telnetLine) write(text string) error {
+	l.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err := l.conn.Write([]byte(text))
+	if err != nil {
+		l.conn.Close()
+	}
+	return err
+}
+
+// Announce shows a message that arrives while the player may be typing, then
+// redraws their prompt and whatever they've typed so far.
+func (l *telnetLine) Announce(message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	text := "\r\x1b[K" + message + "\n" + l.prompt
+	if l.echo {
+		text += string(l.line)
+	}
+	l.write(strings.ReplaceAll(text, "\n", "\r\n"))
+}
+
+// Hangup says a last message and closes the connection, after any output
+// already on its way.
+func (l *telnetLine) Hangup(message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.write(strings.ReplaceAll("\r\x1b[K"+message, "\n", "\r\n"))
+	l.conn.Close()
+}
+
+func (l *telnetLine) Read(p []byte) (int, error) {
+	for len(l.pending) == 0 {
+		if err := l.readByte(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, l.pending)
+	l.pending = l.pending[n:]
+	return n, nil
+}
+
+func (l *telnetLine) readByte() error {
+	b, err := l.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if b == telnetIAC {
+		return l.readCommand()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Telnet ends lines with CR LF or CR NUL
+	afterCR := l.afterCR
+	l.afterCR = b == '\r'
+
+	switch {
+	case afterCR && (b == '\n' || b == 0):
+	case b == '\r' || b == '\n':
+		l.pending = append(append(l.pending, l.line...), '\n')
+		l.line = l.line[:0]
+		l.prompt = ""
+		l.sendEcho("\r\n")
+	case b == 0x7f || b == 0x08: // backspace
+		if len(l.line) > 0 {
+			_, size := utf8.DecodeLastRune(l.line)
+			l.line = l.line[:len(l.line)-size]
+			l.sendEcho("\b \b")
+		}
+	case b == 0x15: // Ctrl+U erases the line
+		l.sendEcho(strings.Repeat("\b \b", utf8.RuneCount(l.line)))
+		l.line = l.line[:0]
+	case b == 0x03 || (b == 0x04 && len(l.line) == 0): // Ctrl+C, or Ctrl+D on an empty line
+		return io.EOF
+	case b < 0x20:
+		// Ignore other control characters
+	case len(l.line) < maxLineLength:
+		l.line = append(l.line, b)
+		l.sendEcho(string(b))
+	}
+	return nil
+}
+
+// readCommand handles the telnet command following an IAC byte.
+func (l *telnetLine) readCommand() error {
+	command, err := l.r.ReadByte()
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case telnetDO, telnetDONT, telnetWILL, telnetWONT:
+		option, err := l.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if option == telnetEcho && (command == telnetDO || command == telnetDONT) {
+			l.mu.Lock()
+			l.echo = command == telnetDO
+			l.mu.Unlock()
+		}
+	case telnetSB:
+		// Skip subnegotiation up to IAC SE
+		for {
+			b, err := l.r.ReadByte()
+			if err != nil {
+				return err
+			}
+			if b == telnetIAC {
+				if next, err := l.r.ReadByte(); err != nil || next == telnetSE {
+					return err
+				}
+			}
+		}
+	case telnetIAC:
+		// An escaped 255 byte, which can't appear in UTF-8 text
+	}
+	return nil
+}
+
+func (l *telnetLine) sendEcho(text string) {
+	if l.echo && text != "" {
+		l.write(text)
+	}
+}
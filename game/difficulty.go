@@ -0,0 +1,97 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	DifficultyEasy   = "easy"
+	DifficultyNormal = "normal"
+	DifficultyHard   = "hard"
+	DifficultyExpert = "expert"
+)
+
+// DifficultyLevel sets how big a generated scenario is and how much help the
+// player gets while playing it.
+type DifficultyLevel struct {
+	Name        string
+	MinRooms    int
+	MaxRooms    int
+	Puzzles     int // at least this many
+	Items       int
+	HiddenItems int
+
+	HintThresholdScale float64 // progressive hints unlock later above 1
+	HintCommand        bool    // whether "hint" helps at all
+	Timed              bool    // gets a timer even if the scenario had none
+	TimerScale         float64
+}
+
+var DifficultyLevels = []DifficultyLevel{
+	{Name: DifficultyEasy, MinRooms: 2, MaxRooms: 3, Puzzles: 2, Items: 4, HiddenItems: 2, HintThresholdScale: 0.5, HintCommand: true, TimerScale: 1.5},
+	{Name: DifficultyNormal, MinRooms: 3, MaxRooms: 4, Puzzles: 3, Items: 5, HiddenItems: 3, HintThresholdScale: 1, HintCommand: true, TimerScale: 1},
+	{Name: DifficultyHard, MinRooms: 4, MaxRooms: 5, Puzzles: 4, Items: 7, HiddenItems: 6, HintThresholdScale: 1.5, HintCommand: true, Timed: true, TimerScale: 0.75},
+	{Name: DifficultyExpert, MinRooms: 5, MaxRooms: 6, Puzzles: 5, Items: 9, HiddenItems: 9, HintThresholdScale: 2, HintCommand: false, Timed: true, TimerScale: 0.5},
+}
+
+// Difficulty looks up a level by name. An empty name means normal.
+func Difficulty(name string) (DifficultyLevel, error) {
+	if name == "" {
+		name = DifficultyNormal
+	}
+	var names []string
+	for _, level := range DifficultyLevels {
+		if strings.EqualFold(level.Name, name) {
+			return level, nil
+		}
+		names = append(names, level.Name)
+	}
+	return DifficultyLevel{}, fmt.Errorf("unknown difficulty %q (choose %s)", name, strings.Join(names, ", "))
+}
+
+// DifficultyLevel returns the level the scenario was made for, or normal for
+// scenarios that don't say.
+func (s *Scenario) DifficultyLevel() DifficultyLevel {
+	level, err := Difficulty(s.Difficulty)
+	if err != nil {
+		level, _ = Difficulty(DifficultyNormal)
+	}
+	return level
+}
+
+// ApplyDifficulty tunes a newly generated scenario for the level: progressive
+// hints unlock sooner or later and the timer gets longer or shorter. It should
+// only be applied once.
+func (s *Scenario) ApplyDifficulty(level DifficultyLevel) {
+	s.Difficulty = level.Name
+
+	for i := range s.ProgressiveHints {
+		for j := range s.ProgressiveHints[i].Triggers {
+			trigger := &s.ProgressiveHints[i].Triggers[j]
+			trigger.Threshold = int(math.Max(1, math.Ceil(float64(trigger.Threshold)*level.HintThresholdScale)))
+		}
+	}
+
+	if s.Timer == nil && level.Timed {
+		s.Timer = &Timer{
+			Mode:  TimerModeRealTime,
+			Limit: 8 * 60 * len(s.Rooms),
+			Warnings: []TimerWarning{
+				{Remaining: 5 * 60, Message: "Five minutes left. You need to hurry."},
+				{Remaining: 60, Message: "One minute left!"},
+			},
+		}
+	}
+	if s.Timer != nil {
+		s.Timer.Limit = int(math.Max(1, math.Round(float64(s.Timer.Limit)*level.TimerScale)))
+		var warnings []TimerWarning
+		for _, warning := range s.Timer.Warnings {
+			if warning.Remaining < s.Timer.Limit {
+				warnings = append(warnings, warning)
+			}
+		}
+		s.Timer.Warnings = warnings
+	}
+}
@@ -2,45 +2,90 @@ package game
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 )
 
 type GameState struct {
 	Scenario          *Scenario         `json:"scenario"`
-	CurrentRoom       string            `json:"current_room"`
-	Inventory         []string          `json:"inventory"`
-	SolvedPuzzles     []string          `json:"solved_puzzles"`
-	DiscoveredItems   []string          `json:"discovered_items"`
-	PerformedActions  []string          `json:"performed_actions"`
+	WorldState
 	FailedAttempts    map[string]int    `json:"failed_attempts"`
 	CommandAttempts   int               `json:"command_attempts"`
 	Moves             int               `json:"moves"`
+	Undos             int               `json:"undos"`
+	HintsUsed         int               `json:"hints_used"`
+	RoomsVisited      []string          `json:"rooms_visited"`
 	StartTime         time.Time         `json:"start_time"`
 	GameWon           bool              `json:"game_won"`
+	Elapsed           time.Duration     `json:"elapsed"`
+	GameLost          bool              `json:"game_lost"`
+	LossReason        string            `json:"loss_reason,omitempty"`
+	EndingMessage     string            `json:"ending_message,omitempty"`
+	TimerWarningsShown []int            `json:"timer_warnings_shown"`
+	HintsUnlocked     []int             `json:"hints_unlocked"`
+	HintsShown        []int             `json:"hints_shown,omitempty"`
+	SecretsFound      []string          `json:"secrets_found,omitempty"`
+	Achievements      []string          `json:"achievements,omitempty"`
 	LastAction        string            `json:"last_action"`
 	LastResult        string            `json:"last_result"`
 }
 
 type Engine struct {
-	state *GameState
+	state        *GameState
+	clockStarted time.Time
+	history      []WorldState
+	checkpoint   *GameState
+	voice        DialogueVoice
+
+	events         []Event
+	checkpointDue  bool
+	subscribers    map[int]func(Event)
+	nextSubscriber int
+	elsewhere      map[string]bool // items teammates are carrying, so not in any room
 }
 
 func NewEngine(scenario *Scenario) *Engine {
 	return &Engine{
-		state: &GameState{
-			Scenario:         scenario,
-			CurrentRoom:      scenario.Rooms[0].ID, // Start in first room
-			Inventory:        []string{},
-			SolvedPuzzles:    []string{},
-			DiscoveredItems:  []string{},
-			PerformedActions: []string{},
-			FailedAttempts:   make(map[string]int),
-			CommandAttempts:  0,
-			Moves:            0,
-			StartTime:        time.Now(),
-			GameWon:          false,
-		},
+		state:        newGameState(scenario),
+		clockStarted: time.Now(),
+	}
+}
+
+// NewEngineAt starts a session in the given room holding the given items, so a
+// scenario can be playtested from the middle.
+func NewEngineAt(scenario *Scenario, roomID string, inventory []string) (*Engine, error) {
+	if _, err := scenario.GetRoom(roomID); err != nil {
+		return nil, err
+	}
+	
+	engine := NewEngine(scenario)
+	engine.state.CurrentRoom = roomID
+	engine.state.visitRoom(roomID)
+	engine.state.setRoomLocked(roomID, false)
+	for _, itemID := range inventory {
+		if _, err := scenario.GetItem(itemID); err != nil {
+			return nil, err
+		}
+		if !engine.HasItem(itemID) {
+			engine.state.Inventory = append(engine.state.Inventory, itemID)
+			engine.state.setItemHidden(itemID, false)
+		}
+	}
+	engine.saveCheckpoint()
+	return engine, nil
+}
+
+func newGameState(scenario *Scenario) *GameState {
+	return &GameState{
+		Scenario:        scenario,
+		WorldState:      newWorldState(scenario),
+		FailedAttempts:  make(map[string]int),
+		CommandAttempts: 0,
+		Moves:           0,
+		RoomsVisited:    []string{scenario.Rooms[0].ID},
+		StartTime:       time.Now(),
+		GameWon:         false,
 	}
 }
 
@@ -48,6 +93,17 @@ func (e *Engine) GetState() *GameState {
 	return e.state
 }
 
+// Snapshot returns a copy of the game state that later commands won't change.
+func (e *Engine) Snapshot() *GameState {
+	return e.state.clone()
+}
+
+// itemHere reports whether an item placed in a room can be found there: it
+// isn't hidden and nobody else has picked it up.
+func (e *Engine) itemHere(itemID string) bool {
+	return !e.state.IsItemHidden(itemID) && !e.elsewhere[itemID]
+}
+
 func (e *Engine) GetCurrentRoom() (*Room, error) {
 	return e.state.Scenario.GetRoom(e.state.CurrentRoom)
 }
@@ -79,7 +135,38 @@ func (e *Engine) IsItemDiscovered(itemID string) bool {
 	return false
 }
 
-func (e *Engine) ProcessCommand(command string) error {
+func (e *Engine) ProcessCommand(command string) (*CommandResult, error) {
+	e.events = nil
+	if e.state.GameWon || e.state.GameLost {
+		e.say("The game is over.")
+		return e.finishCommand(command), nil
+	}
+
+	// Real-time limits can run out while the player is thinking
+	e.checkTimer()
+	if e.state.GameLost {
+		return e.finishCommand(command), nil
+	}
+
+	parts := strings.Fields(strings.ToLower(command))
+	if len(parts) > 0 && parts[0] == "undo" {
+		e.state.LastAction = command
+		err := e.handleUndo(parts[1:])
+		return e.finishCommand(command), err
+	}
+
+	e.pushHistory()
+	err := e.processCommand(command)
+	e.dropUnchangedHistory()
+	e.checkFailConditions()
+	e.checkTimer()
+	e.saveDueCheckpoint()
+	e.checkHintUnlocks()
+	e.checkScoring()
+	return e.finishCommand(command), err
+}
+
+func (e *Engine) processCommand(command string) error {
 	e.state.Moves++
 	e.state.CommandAttempts++
 	e.state.LastAction = command
@@ -89,6 +176,11 @@ func (e *Engine) ProcessCommand(command string) error {
 		return fmt.Errorf("empty command")
 	}
 
+	// Steps of mechanical puzzles take priority over the regular verbs
+	if e.handlePuzzleStep(parts) {
+		return nil
+	}
+	
 	verb := parts[0]
 	
 	switch verb {
@@ -107,19 +199,23 @@ func (e *Engine) ProcessCommand(command string) error {
 		return err
 	case "use":
 		target := strings.Join(parts[1:], " ")
-		err := e.handleUse(parts[1:])
+		// Actions override the default use behavior, as with examine
+		actionProcessed := false
 		if strings.Contains(target, " with ") || strings.Contains(target, " on ") {
 			parts := strings.Split(target, " with ")
 			if len(parts) == 1 {
 				parts = strings.Split(target, " on ")
 			}
 			if len(parts) == 2 {
-				e.processActions("use_with", strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+				actionProcessed = e.processActions("use_with", strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
 			}
 		} else {
-			e.processActions("use", target, "")
+			actionProcessed = e.processActions("use", target, "")
 		}
-		return err
+		if !actionProcessed {
+			return e.handleUse(parts[1:])
+		}
+		return nil
 	case "go", "move", "walk":
 		return e.handleMove(parts[1:])
 	case "inventory", "inv", "i":
@@ -128,8 +224,18 @@ func (e *Engine) ProcessCommand(command string) error {
 		return e.handleSolve(parts[1:])
 	case "hint":
 		return e.handleHint(parts[1:])
+	case "talk", "speak":
+		return e.handleTalk(parts[1:])
+	case "ask":
+		return e.handleAsk(parts[1:])
+	case "give", "trade":
+		return e.handleGive(parts[1:])
+	case "enter", "dial", "type", "punch":
+		return e.handleEnter(parts[1:])
+	case "arrange", "order":
+		return e.handleArrange(parts[1:])
 	default:
-		e.state.LastResult = "I don't understand that command."
+		e.say("I don't understand that command.")
 		return nil
 	}
 }
@@ -140,7 +246,7 @@ func (e *Engine) handleLook(args []string) error {
 		if err != nil {
 			return err
 		}
-		e.state.LastResult = room.Description
+		e.say(room.Description)
 		return nil
 	}
 	
@@ -151,7 +257,7 @@ func (e *Engine) handleLook(args []string) error {
 	roomDesc := strings.ToLower(room.Description)
 	if strings.Contains(roomDesc, strings.ToLower(target)) {
 		// Generic response for room features
-		e.state.LastResult = fmt.Sprintf("You examine the %s more closely, but don't notice anything special.", target)
+		e.say(fmt.Sprintf("You examine the %s more closely, but don't notice anything special.", target))
 		return nil
 	}
 	
@@ -161,19 +267,37 @@ func (e *Engine) handleLook(args []string) error {
 		if err != nil {
 			continue
 		}
-		if strings.Contains(strings.ToLower(item.Name), target) && !item.Hidden {
-			e.state.LastResult = item.Description
+		if strings.Contains(strings.ToLower(item.Name), target) && e.itemHere(itemID) {
+			e.say(item.Description)
+			return nil
+		}
+	}
+	
+	// Check puzzles in current room
+	for _, puzzleID := range room.Puzzles {
+		puzzle, err := e.state.Scenario.GetPuzzle(puzzleID)
+		if err != nil {
+			continue
+		}
+		if strings.Contains(strings.ToLower(puzzle.Name), target) {
+			e.say(e.describePuzzle(puzzle))
 			return nil
 		}
 	}
 	
-	e.state.LastResult = "You don't see that here."
+	// Check characters in current room
+	if npc := e.findNPC(target); npc != nil {
+		e.say(npc.Description)
+		return nil
+	}
+	
+	e.say("You don't see that here.")
 	return nil
 }
 
 func (e *Engine) handleTake(args []string) error {
 	if len(args) == 0 {
-		e.state.LastResult = "Take what?"
+		e.say("Take what?")
 		return nil
 	}
 	
@@ -186,25 +310,25 @@ func (e *Engine) handleTake(args []string) error {
 			continue
 		}
 		
-		if strings.Contains(strings.ToLower(item.Name), target) && !item.Hidden {
+		if strings.Contains(strings.ToLower(item.Name), target) && e.itemHere(itemID) {
 			if !e.HasItem(itemID) {
 				e.state.Inventory = append(e.state.Inventory, itemID)
-				e.state.LastResult = fmt.Sprintf("You take the %s.", item.Name)
+				e.emit(Event{Type: EventItemTaken, Target: itemID, Message: fmt.Sprintf("You take the %s.", item.Name)})
 				return nil
 			} else {
-				e.state.LastResult = "You already have that."
+				e.say("You already have that.")
 				return nil
 			}
 		}
 	}
 	
-	e.state.LastResult = "You don't see that here."
+	e.say("You don't see that here.")
 	return nil
 }
 
 func (e *Engine) handleUse(args []string) error {
 	if len(args) == 0 {
-		e.state.LastResult = "Use what?"
+		e.say("Use what?")
 		return nil
 	}
 	
@@ -229,12 +353,12 @@ func (e *Engine) handleUse(args []string) error {
 		}
 		
 		if strings.Contains(strings.ToLower(item.Name), target) && item.Usable {
-			e.state.LastResult = fmt.Sprintf("You use the %s.", item.Name)
+			e.say(fmt.Sprintf("You use the %s.", item.Name))
 			return nil
 		}
 	}
 	
-	e.state.LastResult = "You don't have that item or can't use it."
+	e.say("You don't have that item or can't use it.")
 	return nil
 }
 
@@ -277,7 +401,7 @@ func (e *Engine) handleItemCombination(item1Name, item2Name string) error {
 			if err != nil {
 				continue
 			}
-			if strings.Contains(strings.ToLower(item.Name), item2Name) && !item.Hidden {
+			if strings.Contains(strings.ToLower(item.Name), item2Name) && e.itemHere(itemID) {
 				item2 = item
 				item2ID = itemID
 				break
@@ -286,12 +410,12 @@ func (e *Engine) handleItemCombination(item1Name, item2Name string) error {
 	}
 	
 	if item1 == nil {
-		e.state.LastResult = fmt.Sprintf("You don't have %s.", item1Name)
+		e.say(fmt.Sprintf("You don't have %s.", item1Name))
 		return nil
 	}
 	
 	if item2 == nil {
-		e.state.LastResult = fmt.Sprintf("You don't see %s here.", item2Name)
+		e.say(fmt.Sprintf("You don't see %s here.", item2Name))
 		return nil
 	}
 	
@@ -299,12 +423,13 @@ func (e *Engine) handleItemCombination(item1Name, item2Name string) error {
 	if item1ID == "matches" && item2ID == "candle" {
 		// Light the candle
 		e.state.Inventory = append(e.state.Inventory, "lit_candle")
-		e.state.LastResult = "You light the candle with the matches. The room is now brightly illuminated!"
+		e.say("You light the candle with the matches. The room is now brightly illuminated!")
 		
 		// Reveal any hidden items that should be revealed by lighting candle
-		for i := range e.state.Scenario.Items {
-			if e.state.Scenario.Items[i].RevealedBy == "use_matches" {
-				e.state.Scenario.Items[i].Hidden = false
+		for _, item := range e.state.Scenario.Items {
+			if item.RevealedBy == "use_matches" {
+				e.state.setItemHidden(item.ID, false)
+				e.emit(Event{Type: EventItemRevealed, Target: item.ID})
 			}
 		}
 		return nil
@@ -312,17 +437,17 @@ func (e *Engine) handleItemCombination(item1Name, item2Name string) error {
 	
 	// Check if item1 is meant to be used with item2
 	if item1.UseWith == item2ID {
-		e.state.LastResult = fmt.Sprintf("You use the %s with the %s.", item1.Name, item2.Name)
+		e.say(fmt.Sprintf("You use the %s with the %s.", item1.Name, item2.Name))
 		return nil
 	}
 	
-	e.state.LastResult = fmt.Sprintf("You can't use the %s with the %s.", item1.Name, item2.Name)
+	e.say(fmt.Sprintf("You can't use the %s with the %s.", item1.Name, item2.Name))
 	return nil
 }
 
 func (e *Engine) handleMove(args []string) error {
 	if len(args) == 0 {
-		e.state.LastResult = "Go where?"
+		e.say("Go where?")
 		return nil
 	}
 	
@@ -336,26 +461,28 @@ func (e *Engine) handleMove(args []string) error {
 		}
 		
 		if strings.Contains(strings.ToLower(exitRoom.Name), direction) || strings.Contains(strings.ToLower(exitID), direction) {
-			if exitRoom.Locked {
+			if e.state.IsRoomLocked(exitID) {
 				if exitRoom.UnlockKey == "" || !e.HasItem(exitRoom.UnlockKey) {
-					e.state.LastResult = "That way is locked."
+					e.say("That way is locked.")
 					return nil
 				}
 			}
 			
 			e.state.CurrentRoom = exitID
-			e.state.LastResult = fmt.Sprintf("You move to %s.", exitRoom.Name)
+			e.state.visitRoom(exitID)
+			e.emit(Event{Type: EventMoved, Target: exitID, Message: fmt.Sprintf("You move to %s.", exitRoom.Name)})
+			e.checkpointDue = true
 			return nil
 		}
 	}
 	
-	e.state.LastResult = "You can't go that way."
+	e.say("You can't go that way.")
 	return nil
 }
 
 func (e *Engine) handleInventory() error {
 	if len(e.state.Inventory) == 0 {
-		e.state.LastResult = "Your inventory is empty."
+		e.say("Your inventory is empty.")
 		return nil
 	}
 	
@@ -367,18 +494,19 @@ func (e *Engine) handleInventory() error {
 		}
 	}
 	
-	e.state.LastResult = "You have: " + strings.Join(items, ", ")
+	e.say("You have: " + strings.Join(items, ", "))
 	return nil
 }
 
 func (e *Engine) handleSolve(args []string) error {
 	if len(args) == 0 {
-		e.state.LastResult = "Solve what?"
+		e.say("Solve what?")
 		return nil
 	}
 	
 	answer := strings.Join(args, " ")
 	room, _ := e.GetCurrentRoom()
+	mechanical := false
 	
 	for _, puzzleID := range room.Puzzles {
 		puzzle, err := e.state.Scenario.GetPuzzle(puzzleID)
@@ -390,63 +518,122 @@ func (e *Engine) handleSolve(args []string) error {
 			continue
 		}
 		
-		// Check if player has required items
-		hasAllItems := true
-		for _, requiredItem := range puzzle.RequiredItems {
-			if !e.HasItem(requiredItem) {
-				hasAllItems = false
-				break
-			}
-		}
-		
-		if !hasAllItems {
-			e.state.LastResult = "You don't have everything needed to solve this puzzle."
-			return nil
+		// Sequences and code locks are worked through their own commands, not by naming an answer
+		if puzzle.Type == PuzzleTypeSequence || puzzle.IsCodeLock() {
+			mechanical = true
+			continue
 		}
 		
-		if strings.EqualFold(answer, puzzle.Solution) {
-			e.state.SolvedPuzzles = append(e.state.SolvedPuzzles, puzzleID)
-			e.state.LastResult = fmt.Sprintf("Correct! %s", puzzle.Reward)
-			
-			// Check win condition
-			if len(e.state.SolvedPuzzles) >= len(e.state.Scenario.Puzzles) {
-				e.state.GameWon = true
-			}
-			
-			return nil
-		} else {
-			e.state.LastResult = "That's not correct."
-			return nil
-		}
+		e.attemptPuzzle(puzzle, answer)
+		return nil
 	}
 	
-	e.state.LastResult = "There's no puzzle here to solve."
+	if mechanical {
+		e.say("Naming an answer won't help here - try working the mechanism, or enter a code on the lock.")
+		return nil
+	}
+	
+	e.say("There's no puzzle here to solve.")
 	return nil
 }
 
+func (e *Engine) hasRequiredItems(puzzle *Puzzle) bool {
+	for _, requiredItem := range puzzle.RequiredItems {
+		if !e.HasItem(requiredItem) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Engine) solvePuzzle(puzzle *Puzzle) {
+	e.state.SolvedPuzzles = append(e.state.SolvedPuzzles, puzzle.ID)
+	e.emit(Event{Type: EventPuzzleSolved, Target: puzzle.ID, Message: fmt.Sprintf("Correct! %s", puzzle.Reward)})
+	e.processActions("solve", puzzle.ID, "")
+	e.checkpointDue = true
+	
+	// Check win condition
+	if len(e.state.SolvedPuzzles) >= len(e.state.Scenario.Puzzles) {
+		e.state.GameWon = true
+		e.Pause()
+		e.emit(Event{Type: EventGameWon})
+	}
+}
+
 func (e *Engine) handleHint(args []string) error {
 	room, _ := e.GetCurrentRoom()
 	
+	if level := e.state.Scenario.DifficultyLevel(); !level.HintCommand {
+		e.say(fmt.Sprintf("There are no hints on %s difficulty - you're on your own.", level.Name))
+		return nil
+	}
+	
+	if tiers := e.applicableHints(); len(tiers) > 0 {
+		e.state.HintsUsed++
+		for tier, index := range tiers {
+			if !e.hasShownHint(index) {
+				e.state.HintsShown = append(e.state.HintsShown, index)
+				e.say(fmt.Sprintf("Hint %d of %d: %s", tier+1, len(tiers), e.state.Scenario.ProgressiveHints[index].HintText))
+				return nil
+			}
+		}
+		// Every tier has been shown, so repeat the most specific one
+		last := e.state.Scenario.ProgressiveHints[tiers[len(tiers)-1]]
+		e.say(fmt.Sprintf("That's all the help there is here: %s", last.HintText))
+		return nil
+	}
+	
 	if hint, exists := e.state.Scenario.Hints[room.ID]; exists {
-		e.state.LastResult = hint
+		e.state.HintsUsed++
+		e.say(hint)
 	} else {
-		e.state.LastResult = "No hints available for this location."
+		e.say("No hints available for this location.")
 	}
 	
 	return nil
 }
 
+// applicableHints returns the indices of the progressive hints for where the
+// player is, from the gentlest nudge to the most specific. Asking for a hint
+// doesn't wait for a hint's triggers.
+func (e *Engine) applicableHints() []int {
+	var tiers []int
+	for i, hint := range e.state.Scenario.ProgressiveHints {
+		if e.hintApplies(hint) {
+			tiers = append(tiers, i)
+		}
+	}
+	hints := e.state.Scenario.ProgressiveHints
+	sort.SliceStable(tiers, func(i, j int) bool {
+		return hints[tiers[i]].Priority > hints[tiers[j]].Priority
+	})
+	return tiers
+}
+
+// HintsGiven returns the progressive hints "hint" has given so far, in the order given.
+func (e *Engine) HintsGiven() []string {
+	var hints []string
+	for _, index := range e.state.HintsShown {
+		hints = append(hints, e.state.Scenario.ProgressiveHints[index].HintText)
+	}
+	return hints
+}
+
+func (e *Engine) hasShownHint(index int) bool {
+	for _, shown := range e.state.HintsShown {
+		if shown == index {
+			return true
+		}
+	}
+	return false
+}
+
 func (e *Engine) IsGameWon() bool {
 	return e.state.GameWon
 }
 
 func (e *Engine) GetGameStats() string {
-	duration := time.Since(e.state.StartTime)
-	return fmt.Sprintf("Moves: %d, Time: %v, Puzzles solved: %d/%d", 
-		e.state.Moves, 
-		duration.Round(time.Second),
-		len(e.state.SolvedPuzzles),
-		len(e.state.Scenario.Puzzles))
+	return e.Stats().String()
 }
 
 func (e *Engine) processActions(actionType, target, withItem string) bool {
@@ -459,12 +646,10 @@ func (e *Engine) processActions(actionType, target, withItem string) bool {
 			}
 			
 			if e.checkActionConditions(action.Conditions) {
+				e.emit(Event{Type: EventActionTriggered, Target: action.ID, Message: action.Message})
 				e.executeActionEffects(action.Effects)
-				if action.Message != "" {
-					e.state.LastResult = action.Message
-				}
 				
-				if action.OneTimeOnly {
+				if !e.hasPerformedAction(action.ID) {
 					e.state.PerformedActions = append(e.state.PerformedActions, action.ID)
 				}
 				actionProcessed = true
@@ -480,6 +665,11 @@ func (e *Engine) matchesAction(action Action, actionType, target, withItem strin
 		return false
 	}
 	
+	// Puzzle IDs can prefix one another, so solve triggers must match exactly
+	if actionType == "solve" {
+		return strings.EqualFold(target, action.Trigger.Target)
+	}
+	
 	if !strings.Contains(strings.ToLower(target), strings.ToLower(action.Trigger.Target)) {
 		return false
 	}
@@ -519,6 +709,11 @@ func (e *Engine) checkActionConditions(conditions []ActionCondition) bool {
 			if !e.hasPerformedAction(condition.Value) {
 				return false
 			}
+		case "topic_discussed":
+			npcID, topicID, _ := strings.Cut(condition.Value, ".")
+			if !e.HasDiscussedTopic(npcID, topicID) {
+				return false
+			}
 		}
 	}
 	return true
@@ -528,41 +723,37 @@ func (e *Engine) executeActionEffects(effects []ActionEffect) {
 	for _, effect := range effects {
 		switch effect.Type {
 		case "reveal_item":
-			for i := range e.state.Scenario.Items {
-				if e.state.Scenario.Items[i].ID == effect.Target {
-					e.state.Scenario.Items[i].Hidden = false
-					break
-				}
-			}
+			e.state.setItemHidden(effect.Target, false)
+			e.emit(Event{Type: EventItemRevealed, Target: effect.Target})
 		case "hide_item":
-			for i := range e.state.Scenario.Items {
-				if e.state.Scenario.Items[i].ID == effect.Target {
-					e.state.Scenario.Items[i].Hidden = true
-					break
-				}
-			}
+			e.state.setItemHidden(effect.Target, true)
+			e.emit(Event{Type: EventItemHidden, Target: effect.Target})
 		case "unlock_room":
-			for i := range e.state.Scenario.Rooms {
-				if e.state.Scenario.Rooms[i].ID == effect.Target {
-					e.state.Scenario.Rooms[i].Locked = false
-					break
-				}
-			}
+			e.state.setRoomLocked(effect.Target, false)
+			e.emit(Event{Type: EventRoomUnlocked, Target: effect.Target})
 		case "add_inventory":
 			if !e.HasItem(effect.Target) {
 				e.state.Inventory = append(e.state.Inventory, effect.Target)
+				e.emit(Event{Type: EventItemAdded, Target: effect.Target})
 			}
 		case "remove_inventory":
-			for i, item := range e.state.Inventory {
-				if item == effect.Target {
-					e.state.Inventory = append(e.state.Inventory[:i], e.state.Inventory[i+1:]...)
-					break
-				}
+			if e.removeFromInventory(effect.Target) {
+				e.emit(Event{Type: EventItemRemoved, Target: effect.Target})
 			}
 		}
 	}
 }
 
+func (e *Engine) removeFromInventory(itemID string) bool {
+	for i, item := range e.state.Inventory {
+		if item == itemID {
+			e.state.Inventory = append(e.state.Inventory[:i], e.state.Inventory[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
 func (e *Engine) GetProgressiveHints() []string {
 	var hints []string
 	
@@ -575,26 +766,28 @@ func (e *Engine) GetProgressiveHints() []string {
 	return hints
 }
 
-func (e *Engine) shouldShowHint(hint ProgressiveHint) bool {
-	contextMatch := false
-	
-	// Check if hint applies to current context
-	if hint.Context == e.state.CurrentRoom {
-		contextMatch = true
+// checkHintUnlocks emits an event the first time each progressive hint becomes available.
+func (e *Engine) checkHintUnlocks() {
+	for i, hint := range e.state.Scenario.ProgressiveHints {
+		if e.hasUnlockedHint(i) || !e.shouldShowHint(hint) {
+			continue
+		}
+		e.state.HintsUnlocked = append(e.state.HintsUnlocked, i)
+		e.emit(Event{Type: EventHintUnlocked, Target: hint.Context})
 	}
-	
-	// Check if it's a puzzle-specific hint for unsolved puzzles in current room
-	if !contextMatch {
-		room, _ := e.GetCurrentRoom()
-		for _, puzzleID := range room.Puzzles {
-			if hint.Context == puzzleID && !e.IsPuzzleSolved(puzzleID) {
-				contextMatch = true
-				break
-			}
+}
+
+func (e *Engine) hasUnlockedHint(index int) bool {
+	for _, unlocked := range e.state.HintsUnlocked {
+		if unlocked == index {
+			return true
 		}
 	}
-	
-	if !contextMatch {
+	return false
+}
+
+func (e *Engine) shouldShowHint(hint ProgressiveHint) bool {
+	if !e.hintApplies(hint) {
 		return false
 	}
 	
@@ -606,7 +799,7 @@ func (e *Engine) shouldShowHint(hint ProgressiveHint) bool {
 				return true
 			}
 		case "time_spent":
-			duration := time.Since(e.state.StartTime)
+			duration := e.Elapsed()
 			if int(duration.Minutes()) >= trigger.Threshold {
 				return true
 			}
@@ -618,4 +811,27 @@ func (e *Engine) shouldShowHint(hint ProgressiveHint) bool {
 	}
 	
 	return false
+}
+
+// hintApplies reports whether a hint is about the current room or one of its unsolved puzzles.
+func (e *Engine) hintApplies(hint ProgressiveHint) bool {
+	contextMatch := false
+	
+	// Check if hint applies to current context
+	if hint.Context == e.state.CurrentRoom {
+		contextMatch = true
+	}
+	
+	// Check if it's a puzzle-specific hint for unsolved puzzles in current room
+	if !contextMatch {
+		room, _ := e.GetCurrentRoom()
+		for _, puzzleID := range room.Puzzles {
+			if hint.Context == puzzleID && !e.IsPuzzleSolved(puzzleID) {
+				contextMatch = true
+				break
+			}
+		}
+	}
+	
+	return contextMatch
 }
\ No newline at end of file
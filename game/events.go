@@ -0,0 +1,104 @@
+package game
+
+import "strings"
+
+const (
+	EventMessage         = "message"
+	EventItemTaken       = "item_taken"
+	EventItemRevealed    = "item_revealed"
+	EventItemHidden      = "item_hidden"
+	EventItemAdded       = "item_added"
+	EventItemRemoved     = "item_removed"
+	EventRoomUnlocked    = "room_unlocked"
+	EventPuzzleSolved    = "puzzle_solved"
+	EventPuzzleFailed    = "puzzle_failed"
+	EventPuzzleProgress  = "puzzle_progress"
+	EventMoved           = "moved"
+	EventActionTriggered = "action_triggered"
+	EventHintUnlocked    = "hint_unlocked"
+	EventDialogue        = "dialogue"
+	EventItemGiven       = "item_given"
+	EventTimerWarning    = "timer_warning"
+	EventUndone          = "undone"
+	EventGameWon         = "game_won"
+	EventGameLost        = "game_lost"
+
+	// Scoring
+	EventSecretFound       = "secret_found"
+	EventAchievementEarned = "achievement_earned"
+
+	// Team games
+	EventPlayerJoined = "player_joined"
+	EventPlayerLeft   = "player_left"
+	EventPlayerAction = "player_action"
+	EventSaid         = "said"
+)
+
+type Event struct {
+	Type    string `json:"type"`
+	Target  string `json:"target,omitempty"` // item, room, puzzle, action or hint context ID
+	Message string `json:"message,omitempty"`
+}
+
+// CommandResult lists everything that happened while processing one command, in order.
+type CommandResult struct {
+	Command string  `json:"command"`
+	Events  []Event `json:"events"`
+}
+
+func (r *CommandResult) Messages() []string {
+	var messages []string
+	for _, event := range r.Events {
+		if event.Message != "" {
+			messages = append(messages, event.Message)
+		}
+	}
+	return messages
+}
+
+func (r *CommandResult) Text() string {
+	return strings.Join(r.Messages(), " ")
+}
+
+func (r *CommandResult) Has(eventType string) bool {
+	for _, event := range r.Events {
+		if event.Type == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscribe registers a function that is called synchronously for every event
+// the engine emits. It returns a function that removes the subscription.
+func (e *Engine) Subscribe(fn func(Event)) func() {
+	if e.subscribers == nil {
+		e.subscribers = make(map[int]func(Event))
+	}
+	id := e.nextSubscriber
+	e.nextSubscriber++
+	e.subscribers[id] = fn
+
+	return func() {
+		delete(e.subscribers, id)
+	}
+}
+
+func (e *Engine) emit(event Event) {
+	e.events = append(e.events, event)
+	for _, fn := range e.subscribers {
+		fn(event)
+	}
+}
+
+func (e *Engine) say(message string) {
+	e.emit(Event{Type: EventMessage, Message: message})
+}
+
+// finishCommand collects the events of the current command into a result.
+func (e *Engine) finishCommand(command string) *CommandResult {
+	result := &CommandResult{Command: command, Events: e.events}
+	e.events = nil
+	e.state.LastResult = result.Text()
+	return result
+}
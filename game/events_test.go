@@ -0,0 +1,94 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+// eventScenario is the test scenario with a pin hidden in the spoon's handle.
+func eventScenario() *Scenario {
+	scenario := testScenario()
+	scenario.Items = append(scenario.Items, Item{ID: "pin", Name: "Pin", Description: "A hair pin.", Hidden: true})
+	scenario.Rooms[0].Items = append(scenario.Rooms[0].Items, "pin")
+	scenario.Actions = []Action{{
+		ID:          "bend_spoon",
+		Trigger:     ActionTrigger{Type: "examine", Target: "spoon"},
+		Effects:     []ActionEffect{{Type: "reveal_item", Target: "pin"}},
+		Message:     "A pin drops out of the handle.",
+		OneTimeOnly: true,
+	}}
+	return scenario
+}
+
+func TestCommandEvents(t *testing.T) {
+	engine := NewEngine(eventScenario())
+	steps := []struct {
+		command string
+		want    []Event
+	}{
+		{"take spoon", []Event{
+			{Type: EventItemTaken, Target: "spoon", Message: "You take the Spoon."},
+			{Type: EventHintUnlocked, Target: "cell"},
+		}},
+		{"look spoon", []Event{
+			{Type: EventActionTriggered, Target: "bend_spoon", Message: "A pin drops out of the handle."},
+			{Type: EventItemRevealed, Target: "pin"},
+		}},
+		{"solve closed", []Event{
+			{Type: EventPuzzleFailed, Target: "door", Message: "That's not correct."},
+			{Type: EventHintUnlocked, Target: "door"},
+		}},
+		{"solve open", []Event{
+			{Type: EventPuzzleSolved, Target: "door", Message: "Correct! The door swings open."},
+		}},
+		{"go hall", []Event{
+			{Type: EventMoved, Target: "hall", Message: "You move to Hall."},
+		}},
+		{"solve swordfish", []Event{
+			{Type: EventPuzzleSolved, Target: "gate", Message: "Correct! The gate lifts."},
+			{Type: EventGameWon},
+		}},
+	}
+
+	for _, step := range steps {
+		result, err := engine.ProcessCommand(step.command)
+		if err != nil {
+			t.Fatalf("%q: %v", step.command, err)
+		}
+		if result.Command != step.command {
+			t.Errorf("result is for %q, want %q", result.Command, step.command)
+		}
+		if !reflect.DeepEqual(result.Events, step.want) {
+			t.Errorf("%q: events = %+v, want %+v", step.command, result.Events, step.want)
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	engine := NewEngine(eventScenario())
+	var first, second []Event
+	unsubscribe := engine.Subscribe(func(event Event) { first = append(first, event) })
+	engine.Subscribe(func(event Event) { second = append(second, event) })
+
+	result, err := engine.ProcessCommand("look spoon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(first, result.Events) || !reflect.DeepEqual(second, result.Events) {
+		t.Errorf("subscribers got %+v and %+v, want %+v", first, second, result.Events)
+	}
+
+	unsubscribe()
+	first, second = nil, nil
+	result, err = engine.ProcessCommand("take spoon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != nil {
+		t.Errorf("got %+v after unsubscribing", first)
+	}
+	if !reflect.DeepEqual(second, result.Events) {
+		t.Errorf("remaining subscriber got %+v, want %+v", second, result.Events)
+	}
+	unsubscribe()
+}
@@ -0,0 +1,45 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestExampleScenarios checks the scenarios in examples/ match the schema,
+// have no problems and can be won.
+func TestExampleScenarios(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("..", "examples", "*.yaml"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no example scenarios found: %v", err)
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var document interface{}
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		encoded, _ := json.Marshal(jsonValue(document))
+		if mismatches, err := CheckSchema(encoded); err != nil || len(mismatches) > 0 {
+			t.Errorf("%s doesn't match the schema: %v %v", path, mismatches, err)
+		}
+
+		scenario, err := LoadScenarioFile(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if problems := scenario.Validate(); len(problems) > 0 {
+			t.Errorf("%s has problems: %v", path, problems)
+		}
+		if _, err := Solve(scenario, DefaultSolverLimit); err != nil {
+			t.Errorf("%s can't be won: %v", path, err)
+		}
+	}
+}
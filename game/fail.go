@@ -0,0 +1,81 @@
+package game
+
+const (
+	FailTimerExpired    = "timer_expired"
+	FailFailedAttempts  = "failed_attempts"
+	FailActionTriggered = "action_triggered"
+)
+
+type FailCondition struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`                // "timer_expired", "failed_attempts", "action_triggered"
+	Target    string `json:"target,omitempty"`    // puzzle ID for failed_attempts (empty means any), action ID for action_triggered
+	Threshold int    `json:"threshold,omitempty"` // wrong answers allowed before failing
+	Message   string `json:"message"`
+}
+
+func (e *Engine) IsGameLost() bool {
+	return e.state.GameLost
+}
+
+func (e *Engine) GetEndingMessage() string {
+	return e.state.EndingMessage
+}
+
+func (e *Engine) lose(reason, message string) {
+	e.state.GameLost = true
+	e.Pause()
+	e.state.LossReason = reason
+	e.state.EndingMessage = message
+	e.emit(Event{Type: EventGameLost, Target: reason, Message: message})
+}
+
+// checkFailConditions ends the game if any scenario-defined fail condition has been met.
+func (e *Engine) checkFailConditions() {
+	if e.state.GameWon || e.state.GameLost {
+		return
+	}
+
+	for _, condition := range e.state.Scenario.FailConditions {
+		failed := false
+
+		switch condition.Type {
+		case FailFailedAttempts:
+			if condition.Threshold <= 0 {
+				continue
+			}
+			if condition.Target == "" {
+				for _, attempts := range e.state.FailedAttempts {
+					if attempts >= condition.Threshold {
+						failed = true
+					}
+				}
+			} else {
+				failed = e.state.FailedAttempts[condition.Target] >= condition.Threshold
+			}
+		case FailActionTriggered:
+			failed = e.hasPerformedAction(condition.Target)
+		}
+
+		if failed {
+			e.lose(condition.ID, condition.Message)
+			return
+		}
+	}
+}
+
+// expireTimer loses the game, preferring a scenario-defined timer_expired message.
+func (e *Engine) expireTimer() {
+	for _, condition := range e.state.Scenario.FailConditions {
+		if condition.Type == FailTimerExpired {
+			e.lose(condition.ID, condition.Message)
+			return
+		}
+	}
+
+	message := e.state.Scenario.Timer.ExpiredMessage
+	if message == "" {
+		message = "Time's up! The room falls silent as your chance to escape slips away."
+	}
+	e.lose(FailTimerExpired, message)
+}
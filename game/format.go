@@ -0,0 +1,274 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// ScenarioExtensions lists the file extensions scenarios can be loaded from.
+var ScenarioExtensions = []string{".json", ".yaml", ".yml", ".toml"}
+
+// FormatFromPath detects a scenario file's format from its extension.
+func FormatFromPath(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".toml":
+		return FormatTOML, nil
+	}
+	return "", fmt.Errorf("unsupported scenario file %s (use .json, .yaml, .yml or .toml)", path)
+}
+
+// LoadScenarioFile reads a scenario in the format given by the file's extension.
+func LoadScenarioFile(path string) (*Scenario, error) {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeScenario(data, format)
+}
+
+// SaveScenarioFile writes a scenario in the format given by the file's extension.
+func SaveScenarioFile(s *Scenario, path string) error {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	data, err := s.Encode(format)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// DecodeScenario parses a scenario in any supported format. YAML and TOML use
+// the same field names as JSON and go through the same migrations.
+func DecodeScenario(data []byte, format string) (*Scenario, error) {
+	switch format {
+	case FormatJSON:
+		return ScenarioFromJSON(data)
+	case FormatYAML:
+		return ScenarioFromYAML(data)
+	case FormatTOML:
+		return ScenarioFromTOML(data)
+	}
+	return nil, fmt.Errorf("unsupported scenario format %q", format)
+}
+
+func (s *Scenario) Encode(format string) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return s.ToJSON()
+	case FormatYAML:
+		return s.ToYAML()
+	case FormatTOML:
+		return s.ToTOML()
+	}
+	return nil, fmt.Errorf("unsupported scenario format %q", format)
+}
+
+func ScenarioFromYAML(data []byte) (*Scenario, error) {
+	var document interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+	return scenarioFromDocument(document)
+}
+
+func ScenarioFromTOML(data []byte) (*Scenario, error) {
+	var document map[string]interface{}
+	if err := toml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("invalid TOML: %w", err)
+	}
+	return scenarioFromDocument(document)
+}
+
+// ToYAML writes the scenario as YAML, keeping the field order of the JSON form
+// and using block style for multi-line text.
+func (s *Scenario) ToYAML() ([]byte, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	node, err := yamlNode(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func (s *Scenario) ToTOML() ([]byte, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var document interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := toml.NewEncoder(&out).Encode(tomlValue(document)); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// scenarioFromDocument converts a decoded YAML or TOML document into a
+// scenario by way of JSON, so all formats share the JSON field names.
+func scenarioFromDocument(document interface{}) (*Scenario, error) {
+	data, err := json.Marshal(jsonValue(document))
+	if err != nil {
+		return nil, err
+	}
+	return ScenarioFromJSON(data)
+}
+
+// jsonValue makes a YAML document encodable as JSON; YAML allows non-string map keys.
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = jsonValue(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range v {
+			v[i] = jsonValue(item)
+		}
+		return v
+	case []map[string]interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = jsonValue(item)
+		}
+		return converted
+	}
+	return value
+}
+
+// tomlValue prepares a JSON document for TOML, which has no null.
+func tomlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if item == nil {
+				delete(v, key)
+				continue
+			}
+			v[key] = tomlValue(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = tomlValue(item)
+		}
+		return v
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return value
+}
+
+// yamlNode reads the next JSON value from the decoder as a YAML node.
+func yamlNode(decoder *json.Decoder) (*yaml.Node, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '{' {
+			node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := yamlNode(decoder)
+				if err != nil {
+					return nil, err
+				}
+				if value.Tag == "!!null" {
+					// Missing fields load the same as null ones, and read better
+					continue
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string)}, value)
+			}
+			_, err := decoder.Token()
+			return node, err
+		}
+
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for decoder.More() {
+			value, err := yamlNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+		_, err := decoder.Token()
+		return node, err
+	case string:
+		node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: t}
+		if strings.Contains(t, "\n") {
+			node.Style = yaml.LiteralStyle
+		}
+		return node, nil
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(t.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: t.String()}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(t)}, nil
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+}
@@ -0,0 +1,105 @@
+package game
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScenarioRoundTrip(t *testing.T) {
+	hard, _ := Difficulty(DifficultyHard)
+	scenario := GenerateScenario("Sunken Temple", 11, hard)
+	scenario.BackStory = "The water rose overnight.\nNobody came back up."
+	want, err := scenario.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []string{FormatJSON, FormatYAML, FormatTOML} {
+		data, err := scenario.Encode(format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		decoded, err := DecodeScenario(data, format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		got, err := decoded.ToJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s round trip changed the scenario:\n%s", format, got)
+		}
+	}
+}
+
+func TestYAMLKeepsMultilineText(t *testing.T) {
+	scenario := testScenario()
+	scenario.BackStory = "Line one.\nLine two."
+	data, err := scenario.ToYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "backstory: |-\n  Line one.\n  Line two.") {
+		t.Errorf("backstory not written as a block:\n%s", data)
+	}
+}
+
+func TestScenarioFileFormats(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"cell.json", "cell.yml", "cell.TOML"} {
+		path := filepath.Join(dir, name)
+		if err := SaveScenarioFile(testScenario(), path); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		loaded, err := LoadScenarioFile(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if loaded.Theme != "Test Cell" || len(loaded.Rooms) != 2 {
+			t.Errorf("%s loaded as %+v", name, loaded)
+		}
+	}
+
+	if err := SaveScenarioFile(testScenario(), filepath.Join(dir, "cell.txt")); err == nil {
+		t.Error("expected an error saving to an unsupported extension")
+	}
+}
+
+func TestOldYAMLScenariosAreMigrated(t *testing.T) {
+	data := []byte(`
+theme: Old Attic
+rooms:
+  - id: attic
+    name: Attic
+    description: Dusty.
+    items: [box]
+    puzzles: [lock]
+items:
+  - id: box
+    name: Box
+    description: A box.
+  - id: key
+    name: Key
+    description: A key.
+    hidden: true
+    revealed_by: box
+puzzles:
+  - id: lock
+    name: Lock
+    description: A lock.
+    solution: key
+hints:
+  attic: Look in the box.
+`)
+	scenario, err := ScenarioFromYAML(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scenario.SchemaVersion != SchemaVersion || len(scenario.Actions) != 1 || len(scenario.ProgressiveHints) != 1 {
+		t.Errorf("migrated to version %d with %d actions and %d progressive hints, want %d, 1 and 1",
+			scenario.SchemaVersion, len(scenario.Actions), len(scenario.ProgressiveHints), SchemaVersion)
+	}
+}
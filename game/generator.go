@@ -0,0 +1,345 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// GenerateScenario builds a scenario for the theme without an LLM. Rooms form a
+// chain where each door is opened either by a key hidden in the previous room
+// or by solving that room's puzzle, and every puzzle's clue is hidden in its own
+// room, so the result is always solvable. The same theme, seed and difficulty
+// always produce the same scenario. Harder levels have more rooms and hide
+// some of the curios as secrets, and the scenario comes already tuned for the
+// level by ApplyDifficulty.
+func GenerateScenario(theme string, seed int64, level DifficultyLevel) *Scenario {
+	g := &generator{
+		rng:   rand.New(rand.NewSource(seed)),
+		bank:  bankFor(theme),
+		level: level,
+		ids:   make(map[string]bool),
+	}
+	return g.generate(theme)
+}
+
+type generator struct {
+	rng      *rand.Rand
+	bank     wordBank
+	level    DifficultyLevel
+	ids      map[string]bool
+	scenario *Scenario
+	locks    []string
+}
+
+func (g *generator) generate(theme string) *Scenario {
+	bank := g.bank
+	g.scenario = &Scenario{
+		SchemaVersion: SchemaVersion,
+		Theme:         theme,
+		Setting:       bank.Setting,
+		BackStory:     bank.Backstory,
+		WinCondition:  bank.Escape,
+		Hints:         make(map[string]string),
+	}
+
+	roomCount := g.level.MinRooms + g.rng.Intn(g.level.MaxRooms-g.level.MinRooms+1)
+	if limit := bank.maxRooms(); roomCount > limit {
+		roomCount = limit
+	}
+	rooms := g.pickEntries(bank.Rooms, roomCount)
+	features := g.pickStrings(bank.Features, roomCount*2)
+	keys := g.pickStrings(bank.Keys, roomCount-1)
+	notes := g.pickStrings(bank.Notes, roomCount)
+	curios := g.pickEntries(bank.Curios, roomCount)
+	g.locks = g.pickStrings(bank.Locks, len(bank.Locks))
+	riddles := g.rng.Perm(len(bank.Riddles))
+	words := g.pickStrings(bank.Words, len(bank.Words))
+
+	for i, entry := range rooms {
+		g.scenario.Rooms = append(g.scenario.Rooms, Room{
+			ID:     g.uniqueID(entry.Name),
+			Name:   entry.Name,
+			Locked: i > 0,
+		})
+	}
+
+	// Each room hides its clue, so only hide curios beyond that
+	hiddenCurios := g.level.HiddenItems - roomCount
+
+	kinds := []string{PuzzleTypeCombination, PuzzleTypeKeypad, PuzzleTypeCipher, PuzzleTypeRiddle, PuzzleTypeOrdering}
+	g.rng.Shuffle(len(kinds), func(a, b int) { kinds[a], kinds[b] = kinds[b], kinds[a] })
+
+	for i := range g.scenario.Rooms {
+		room := &g.scenario.Rooms[i]
+		clueFeature, otherFeature := features[i*2], features[i*2+1]
+
+		room.Description = fmt.Sprintf("%s %s and %s catch your eye.", rooms[i].Description, capitalize(withArticle(clueFeature)), withArticle(otherFeature))
+		if i > 0 {
+			room.Exits = append(room.Exits, g.scenario.Rooms[i-1].ID)
+		}
+
+		// A flavour item lies in plain sight, or is hidden as a secret
+		hideCurio := i < hiddenCurios
+		curio := g.addItem(curios[i].Name, curios[i].Description, hideCurio)
+		room.Items = append(room.Items, curio)
+		var secret []string
+		if hideCurio {
+			secret = []string{curio}
+			if g.scenario.Scoring == nil {
+				g.scenario.Scoring = &Scoring{}
+			}
+			g.scenario.Scoring.Secrets = append(g.scenario.Scoring.Secrets, curio)
+		}
+
+		// The puzzle's clue is hidden in the first feature
+		kind := kinds[i%len(kinds)]
+		if kind == PuzzleTypeRiddle && len(riddles) == 0 {
+			kind = PuzzleTypeCombination
+		}
+
+		var word string
+		if len(words) > 0 {
+			word = words[i%len(words)]
+		}
+
+		var riddle bankRiddle
+		if kind == PuzzleTypeRiddle {
+			riddle = bank.Riddles[riddles[0]]
+			riddles = riddles[1:]
+		}
+
+		puzzle, clueDescription := g.buildPuzzle(kind, word, riddle)
+		clue := g.addItem(notes[i], clueDescription, true)
+		if kind != PuzzleTypeRiddle {
+			puzzle.RequiredItems = []string{clue}
+		}
+		room.Items = append(room.Items, clue)
+		room.Puzzles = []string{puzzle.ID}
+		g.addSearchAction(room.ID, clueFeature, fmt.Sprintf("You search the %s and find %s.", clueFeature, withArticle(notes[i])), clue)
+
+		last := i == len(g.scenario.Rooms)-1
+		if last {
+			puzzle.Reward = fmt.Sprintf("With a final click, the way out opens. %s", bank.Escape)
+		} else {
+			next := &g.scenario.Rooms[i+1]
+			room.Exits = append(room.Exits, next.ID)
+
+			if g.rng.Intn(2) == 0 {
+				// The door is opened by a key hidden in the second feature
+				key := g.addItem(keys[i], fmt.Sprintf("%s. It must open something nearby.", capitalize(withArticle(keys[i]))), true)
+				room.Items = append(room.Items, key)
+				next.UnlockKey = key
+				message := fmt.Sprintf("Tucked inside the %s you find %s.", otherFeature, withArticle(keys[i]))
+				if hideCurio {
+					message = fmt.Sprintf("Tucked inside the %s you find %s and %s.", otherFeature, withArticle(keys[i]), withArticle(curios[i].Name))
+				}
+				g.addSearchAction(room.ID, otherFeature, message, append([]string{key}, secret...)...)
+				puzzle.Reward = "Something shifts inside the walls. You're one step closer to escaping."
+			} else {
+				// Solving the puzzle opens the door
+				g.scenario.Actions = append(g.scenario.Actions, Action{
+					ID:          g.uniqueID("open_" + next.ID),
+					Trigger:     ActionTrigger{Type: "solve", Target: puzzle.ID},
+					Effects:     []ActionEffect{{Type: "unlock_room", Target: next.ID}},
+					Message:     fmt.Sprintf("Somewhere nearby, a heavy lock releases. The way to the %s is open.", next.Name),
+					OneTimeOnly: true,
+				})
+				message := fmt.Sprintf("You search the %s thoroughly, but find nothing useful.", otherFeature)
+				if hideCurio {
+					message = fmt.Sprintf("You search the %s thoroughly and find %s.", otherFeature, withArticle(curios[i].Name))
+				}
+				g.addSearchAction(room.ID, otherFeature, message, secret...)
+				puzzle.Reward = "The mechanism whirs into life."
+			}
+		}
+
+		g.scenario.Puzzles = append(g.scenario.Puzzles, puzzle)
+		g.scenario.Hints[room.ID] = fmt.Sprintf("Search the %s and the %s carefully, then look at the %s.", clueFeature, otherFeature, strings.ToLower(puzzle.Name))
+		g.scenario.ProgressiveHints = append(g.scenario.ProgressiveHints,
+			ProgressiveHint{
+				Context:  room.ID,
+				Triggers: []HintTrigger{{Type: "commands_tried", Threshold: 5 + 8*i}},
+				HintText: fmt.Sprintf("The %s deserves a closer look.", clueFeature),
+				Priority: 2,
+			},
+			ProgressiveHint{
+				Context:  puzzle.ID,
+				Triggers: []HintTrigger{{Type: "failed_attempts", Threshold: 2}},
+				HintText: fmt.Sprintf("Read the %s again - it holds the key to the %s.", notes[i], strings.ToLower(puzzle.Name)),
+				Priority: 1,
+			},
+		)
+	}
+
+	// Only timed levels get a timer, and it runs out in the theme's own words
+	g.scenario.ApplyDifficulty(g.level)
+	if g.scenario.Timer != nil {
+		g.scenario.Timer.ExpiredMessage = bank.Expired
+	}
+
+	return g.scenario
+}
+
+// buildPuzzle creates a puzzle of the given kind along with the description of
+// the clue item that solves it.
+func (g *generator) buildPuzzle(kind, word string, riddle bankRiddle) (Puzzle, string) {
+	var lockName string
+	if kind == PuzzleTypeCombination || kind == PuzzleTypeKeypad {
+		lockName = g.locks[0]
+		g.locks = append(g.locks[1:], lockName)
+	}
+
+	switch kind {
+	case PuzzleTypeCombination:
+		code := fmt.Sprintf("%d-%d-%d", g.rng.Intn(10), g.rng.Intn(10), g.rng.Intn(10))
+		return Puzzle{
+			ID:          g.uniqueID(lockName),
+			Name:        lockName,
+			Description: fmt.Sprintf("The %s is secured by a three-number combination.", lockName),
+			Solution:    code,
+			Type:        PuzzleTypeCombination,
+		}, fmt.Sprintf("Three numbers are scrawled across it: %s.", code)
+	case PuzzleTypeKeypad:
+		code := fmt.Sprintf("%04d", g.rng.Intn(10000))
+		return Puzzle{
+			ID:          g.uniqueID(lockName),
+			Name:        lockName,
+			Description: fmt.Sprintf("The %s waits for a four-digit code.", lockName),
+			Solution:    code,
+			Type:        PuzzleTypeKeypad,
+		}, fmt.Sprintf("Four digits are circled in red: %s.", strings.Join(strings.Split(code, ""), " "))
+	case PuzzleTypeCipher:
+		shift := 1 + g.rng.Intn(25)
+		return Puzzle{
+			ID:          g.uniqueID("coded message"),
+			Name:        "Coded Message",
+			Description: "A coded message has been left here. Decode it and say the word to solve it.",
+			Solution:    word,
+			Type:        PuzzleTypeCipher,
+			Cipher:      &Cipher{Method: CipherCaesar, Shift: shift},
+		}, fmt.Sprintf("A note explaining a code: every letter has been moved %d places forward in the alphabet.", shift)
+	case PuzzleTypeRiddle:
+		return Puzzle{
+			ID:          g.uniqueID("riddle"),
+			Name:        "Riddle",
+			Description: riddle.Question,
+			Solution:    riddle.Answer,
+			Answers:     riddle.Answers,
+			Type:        PuzzleTypeRiddle,
+		}, fmt.Sprintf("Someone has copied out the riddle and underlined it twice: %s", riddle.Question)
+	default:
+		pieces := g.pickStrings(g.bank.Symbols, 3+g.rng.Intn(2))
+		order := strings.Join(pieces, ", ")
+		return Puzzle{
+			ID:          g.uniqueID("symbol panel"),
+			Name:        "Symbol Panel",
+			Description: "A row of sliding tiles, each marked with a symbol, waits to be arranged in the right order.",
+			Solution:    order,
+			Type:        PuzzleTypeOrdering,
+		}, fmt.Sprintf("A faded sketch shows a row of symbols: %s.", strings.Join(pieces, ", then "))
+	}
+}
+
+func (g *generator) addItem(name, description string, hidden bool) string {
+	id := g.uniqueID(name)
+	g.scenario.Items = append(g.scenario.Items, Item{
+		ID:          id,
+		Name:        name,
+		Description: description,
+		Usable:      true,
+		Hidden:      hidden,
+	})
+	return id
+}
+
+// addSearchAction makes examining a feature in a room reveal items.
+func (g *generator) addSearchAction(roomID, feature, message string, itemIDs ...string) {
+	words := strings.Fields(feature)
+	action := Action{
+		ID:          g.uniqueID("search_" + feature),
+		Trigger:     ActionTrigger{Type: "examine", Target: words[len(words)-1]},
+		Conditions:  []ActionCondition{{Type: "in_room", Value: roomID}},
+		Message:     message,
+		OneTimeOnly: true,
+	}
+	for _, itemID := range itemIDs {
+		action.Effects = append(action.Effects, ActionEffect{Type: "reveal_item", Target: itemID})
+		for i := range g.scenario.Items {
+			if g.scenario.Items[i].ID == itemID {
+				g.scenario.Items[i].RevealedBy = action.ID
+			}
+		}
+	}
+	g.scenario.Actions = append(g.scenario.Actions, action)
+}
+
+// maxRooms is how many rooms the bank has enough words to fill.
+func (b wordBank) maxRooms() int {
+	limit := len(b.Rooms)
+	for _, n := range []int{len(b.Features) / 2, len(b.Keys) + 1, len(b.Notes), len(b.Curios)} {
+		if n < limit {
+			limit = n
+		}
+	}
+	return limit
+}
+
+func (g *generator) uniqueID(name string) string {
+	var id strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			id.WriteRune(r)
+		case r == ' ' || r == '-' || r == '_':
+			id.WriteRune('_')
+		}
+	}
+
+	base := id.String()
+	unique := base
+	for n := 2; g.ids[unique]; n++ {
+		unique = fmt.Sprintf("%s_%d", base, n)
+	}
+	g.ids[unique] = true
+	return unique
+}
+
+func (g *generator) pickStrings(from []string, n int) []string {
+	picked := make([]string, 0, n)
+	for _, i := range g.rng.Perm(len(from)) {
+		if len(picked) == n {
+			break
+		}
+		picked = append(picked, from[i])
+	}
+	return picked
+}
+
+func (g *generator) pickEntries(from []bankEntry, n int) []bankEntry {
+	picked := make([]bankEntry, 0, n)
+	for _, i := range g.rng.Perm(len(from)) {
+		if len(picked) == n {
+			break
+		}
+		picked = append(picked, from[i])
+	}
+	return picked
+}
+
+func withArticle(noun string) string {
+	if noun == "" {
+		return noun
+	}
+	if strings.ContainsRune("aeiou", rune(strings.ToLower(noun)[0])) {
+		return "an " + noun
+	}
+	return "a " + noun
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
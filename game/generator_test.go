@@ -0,0 +1,40 @@
+package game
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGenerateScenarioTimerFollowsDifficulty(t *testing.T) {
+	for _, level := range DifficultyLevels {
+		scenario := GenerateScenario("Haunted Manor", 1, level)
+		if scenario.Difficulty != level.Name {
+			t.Errorf("%s: difficulty = %q", level.Name, scenario.Difficulty)
+		}
+		if level.Timed != (scenario.Timer != nil) {
+			t.Errorf("%s: timed = %v, but timer = %+v", level.Name, level.Timed, scenario.Timer)
+			continue
+		}
+		if scenario.Timer != nil && scenario.Timer.ExpiredMessage != bankFor("Haunted Manor").Expired {
+			t.Errorf("%s: timer runs out with %q, want the theme's message", level.Name, scenario.Timer.ExpiredMessage)
+		}
+	}
+}
+
+func TestGenerateScenarioIsValidAndRepeatable(t *testing.T) {
+	for _, level := range DifficultyLevels {
+		scenario := GenerateScenario("Pirate Ship", 99, level)
+		if problems := scenario.Validate(); len(problems) > 0 {
+			t.Errorf("%s: %v", level.Name, problems)
+		}
+
+		first, err := scenario.ToJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, _ := GenerateScenario("Pirate Ship", 99, level).ToJSON()
+		if !bytes.Equal(first, second) {
+			t.Errorf("%s: the same seed generated different scenarios", level.Name)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	GraphFormatDOT     = "dot"
+	GraphFormatMermaid = "mermaid"
+)
+
+const (
+	nodeRoom   = "room"
+	nodeItem   = "item"
+	nodePuzzle = "puzzle"
+	nodeAction = "action"
+	nodeNPC    = "npc"
+)
+
+type graphNode struct {
+	id     string
+	label  string
+	kind   string
+	hidden bool // hidden items and locked rooms are drawn dashed
+	onPath bool
+}
+
+type graphEdge struct {
+	from, to string
+	label    string
+	dashed   bool
+	onPath   bool
+}
+
+// scenarioGraph is the dependency structure of a scenario: rooms and their
+// exits, where items and puzzles are, what actions need and do, and which keys
+// open which doors.
+type scenarioGraph struct {
+	nodes []*graphNode
+	edges []*graphEdge
+	index map[string]*graphNode
+}
+
+// RenderGraph draws the scenario as a Graphviz DOT or Mermaid graph. If a
+// solution is given, the rooms, items, actions and puzzles on the winning path
+// are highlighted.
+func RenderGraph(s *Scenario, format string, solution []SolutionStep) (string, error) {
+	g := buildGraph(s)
+	g.highlight(s, solution)
+
+	switch format {
+	case GraphFormatDOT:
+		return g.dot(s.Theme), nil
+	case GraphFormatMermaid:
+		return g.mermaid(), nil
+	}
+	return "", fmt.Errorf("unsupported graph format %q (use dot or mermaid)", format)
+}
+
+func buildGraph(s *Scenario) *scenarioGraph {
+	g := &scenarioGraph{index: make(map[string]*graphNode)}
+
+	for _, room := range s.Rooms {
+		g.addNode(nodeRoom, room.ID, room.Name, room.Locked)
+	}
+	for _, item := range s.Items {
+		g.addNode(nodeItem, item.ID, item.Name, item.Hidden)
+	}
+	for _, puzzle := range s.Puzzles {
+		label := puzzle.Name
+		if puzzle.Type != "" && puzzle.Type != PuzzleTypeAnswer {
+			label = fmt.Sprintf("%s (%s)", puzzle.Name, puzzle.Type)
+		}
+		g.addNode(nodePuzzle, puzzle.ID, label, false)
+	}
+	for _, npc := range s.NPCs {
+		g.addNode(nodeNPC, npc.ID, npc.Name, false)
+	}
+	for _, action := range s.Actions {
+		label := fmt.Sprintf("%s %s", action.Trigger.Type, action.Trigger.Target)
+		if action.Trigger.With != "" {
+			label += " with " + action.Trigger.With
+		}
+		g.addNode(nodeAction, action.ID, label, false)
+	}
+
+	for _, room := range s.Rooms {
+		for _, exitID := range room.Exits {
+			exit, err := s.GetRoom(exitID)
+			if err != nil {
+				continue
+			}
+			label := ""
+			if exit.Locked {
+				label = "locked"
+			}
+			g.addEdge(nodeRoom, room.ID, nodeRoom, exitID, label, exit.Locked)
+		}
+		if room.UnlockKey != "" {
+			g.addEdge(nodeItem, room.UnlockKey, nodeRoom, room.ID, "unlocks", false)
+		}
+		for _, itemID := range room.Items {
+			g.addEdge(nodeRoom, room.ID, nodeItem, itemID, "", false)
+		}
+		for _, puzzleID := range room.Puzzles {
+			g.addEdge(nodeRoom, room.ID, nodePuzzle, puzzleID, "", false)
+		}
+		for _, npcID := range room.NPCs {
+			g.addEdge(nodeRoom, room.ID, nodeNPC, npcID, "", false)
+		}
+	}
+
+	for _, puzzle := range s.Puzzles {
+		for _, itemID := range puzzle.RequiredItems {
+			g.addEdge(nodeItem, itemID, nodePuzzle, puzzle.ID, "required", false)
+		}
+	}
+
+	for _, action := range s.Actions {
+		switch action.Trigger.Type {
+		case "solve":
+			g.addEdge(nodePuzzle, action.Trigger.Target, nodeAction, action.ID, "when solved", false)
+		case "use", "use_with", "take":
+			g.addEdge(nodeItem, action.Trigger.Target, nodeAction, action.ID, action.Trigger.Type, false)
+		}
+		if action.Trigger.With != "" {
+			g.addEdge(nodeItem, action.Trigger.With, nodeAction, action.ID, "with", false)
+		}
+		g.addConditionEdges(action.Conditions, nodeAction, action.ID)
+		g.addEffectEdges(action.Effects, nodeAction, action.ID)
+	}
+
+	for _, npc := range s.NPCs {
+		for _, topic := range npc.Topics {
+			g.addConditionEdges(topic.Conditions, nodeNPC, npc.ID)
+			g.addEffectEdges(topic.Effects, nodeNPC, npc.ID)
+		}
+		for _, trade := range npc.Trades {
+			g.addEdge(nodeItem, trade.Wants, nodeNPC, npc.ID, "wants", false)
+			if trade.Gives != "" {
+				g.addEdge(nodeNPC, npc.ID, nodeItem, trade.Gives, "gives", false)
+			}
+			g.addEffectEdges(trade.Effects, nodeNPC, npc.ID)
+		}
+	}
+
+	return g
+}
+
+func (g *scenarioGraph) addConditionEdges(conditions []ActionCondition, kind, id string) {
+	for _, condition := range conditions {
+		switch condition.Type {
+		case "has_item":
+			g.addEdge(nodeItem, condition.Value, kind, id, "needs", true)
+		case "in_room":
+			g.addEdge(nodeRoom, condition.Value, kind, id, "", true)
+		case "puzzle_solved":
+			g.addEdge(nodePuzzle, condition.Value, kind, id, "after", true)
+		case "action_performed":
+			g.addEdge(nodeAction, condition.Value, kind, id, "after", true)
+		}
+	}
+}
+
+func (g *scenarioGraph) addEffectEdges(effects []ActionEffect, kind, id string) {
+	for _, effect := range effects {
+		switch effect.Type {
+		case "reveal_item":
+			g.addEdge(kind, id, nodeItem, effect.Target, "reveals", false)
+		case "hide_item":
+			g.addEdge(kind, id, nodeItem, effect.Target, "hides", false)
+		case "unlock_room":
+			g.addEdge(kind, id, nodeRoom, effect.Target, "unlocks", false)
+		case "add_inventory":
+			g.addEdge(kind, id, nodeItem, effect.Target, "gives", false)
+		case "remove_inventory":
+			g.addEdge(kind, id, nodeItem, effect.Target, "takes", false)
+		}
+	}
+}
+
+func (g *scenarioGraph) addNode(kind, id, label string, hidden bool) {
+	node := &graphNode{id: graphID(kind, id), label: label, kind: kind, hidden: hidden}
+	if _, exists := g.index[node.id]; exists {
+		return
+	}
+	g.index[node.id] = node
+	g.nodes = append(g.nodes, node)
+}
+
+// addEdge links two nodes, skipping references to things the scenario doesn't define.
+func (g *scenarioGraph) addEdge(fromKind, fromID, toKind, toID, label string, dashed bool) {
+	from, to := graphID(fromKind, fromID), graphID(toKind, toID)
+	if g.index[from] == nil || g.index[to] == nil {
+		return
+	}
+	g.edges = append(g.edges, &graphEdge{from: from, to: to, label: label, dashed: dashed})
+}
+
+// highlight marks what the winning path touches: the rooms walked through, the
+// items picked up or revealed, and the actions and puzzles completed.
+func (g *scenarioGraph) highlight(s *Scenario, solution []SolutionStep) {
+	if len(solution) == 0 {
+		return
+	}
+
+	mark := func(kind, id string) string {
+		nodeID := graphID(kind, id)
+		if node := g.index[nodeID]; node != nil {
+			node.onPath = true
+		}
+		return nodeID
+	}
+	markEdge := func(from, to string) {
+		for _, edge := range g.edges {
+			if edge.from == from && edge.to == to {
+				edge.onPath = true
+			}
+		}
+	}
+
+	room := mark(nodeRoom, s.Rooms[0].ID)
+	for _, step := range solution {
+		for _, event := range step.Events {
+			switch event.Type {
+			case EventMoved:
+				next := mark(nodeRoom, event.Target)
+				markEdge(room, next)
+				room = next
+			case EventItemTaken, EventItemAdded:
+				markEdge(room, mark(nodeItem, event.Target))
+			case EventItemRevealed:
+				mark(nodeItem, event.Target)
+			case EventActionTriggered:
+				action := mark(nodeAction, event.Target)
+				for _, edge := range g.edges {
+					if edge.from == action && g.index[edge.to].kind != nodeAction {
+						edge.onPath = true
+					}
+				}
+			case EventPuzzleSolved:
+				markEdge(room, mark(nodePuzzle, event.Target))
+			}
+		}
+	}
+}
+
+func (g *scenarioGraph) dot(title string) string {
+	var b strings.Builder
+	b.WriteString("digraph scenario {\n")
+	fmt.Fprintf(&b, "  label=%s;\n", dotQuote(title))
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [fontname=\"Helvetica\"];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n\n")
+
+	shapes := map[string]string{
+		nodeRoom:   "box",
+		nodeItem:   "ellipse",
+		nodePuzzle: "hexagon",
+		nodeAction: "diamond",
+		nodeNPC:    "house",
+	}
+	for _, node := range g.nodes {
+		attrs := []string{"label=" + dotQuote(node.label), "shape=" + shapes[node.kind]}
+		if node.hidden {
+			attrs = append(attrs, "style=dashed")
+		}
+		if node.onPath {
+			attrs = append(attrs, "color=red", "penwidth=2")
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", node.id, strings.Join(attrs, ", "))
+	}
+	b.WriteString("\n")
+
+	for _, edge := range g.edges {
+		var attrs []string
+		if edge.label != "" {
+			attrs = append(attrs, "label="+dotQuote(edge.label))
+		}
+		if edge.dashed {
+			attrs = append(attrs, "style=dashed")
+		}
+		if edge.onPath {
+			attrs = append(attrs, "color=red", "penwidth=2")
+		}
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, "  %s -> %s [%s];\n", edge.from, edge.to, strings.Join(attrs, ", "))
+		} else {
+			fmt.Fprintf(&b, "  %s -> %s;\n", edge.from, edge.to)
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (g *scenarioGraph) mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+
+	shapes := map[string][2]string{
+		nodeRoom:   {"[", "]"},
+		nodeItem:   {"([", "])"},
+		nodePuzzle: {"{{", "}}"},
+		nodeAction: {"{", "}"},
+		nodeNPC:    {"[/", "/]"},
+	}
+	var hidden, onPath []string
+	for _, node := range g.nodes {
+		shape := shapes[node.kind]
+		fmt.Fprintf(&b, "  %s%s\"%s\"%s\n", node.id, shape[0], mermaidEscape(node.label), shape[1])
+		if node.hidden {
+			hidden = append(hidden, node.id)
+		}
+		if node.onPath {
+			onPath = append(onPath, node.id)
+		}
+	}
+
+	var pathEdges []string
+	for i, edge := range g.edges {
+		arrow := "-->"
+		if edge.dashed {
+			arrow = "-.->"
+		}
+		if edge.label != "" {
+			fmt.Fprintf(&b, "  %s %s|%s| %s\n", edge.from, arrow, mermaidEscape(edge.label), edge.to)
+		} else {
+			fmt.Fprintf(&b, "  %s %s %s\n", edge.from, arrow, edge.to)
+		}
+		if edge.onPath {
+			pathEdges = append(pathEdges, fmt.Sprint(i))
+		}
+	}
+
+	if len(hidden) > 0 {
+		b.WriteString("  classDef hidden stroke-dasharray: 5 5\n")
+		fmt.Fprintf(&b, "  class %s hidden\n", strings.Join(hidden, ","))
+	}
+	if len(onPath) > 0 {
+		b.WriteString("  classDef path stroke:#e00,stroke-width:3px\n")
+		fmt.Fprintf(&b, "  class %s path\n", strings.Join(onPath, ","))
+	}
+	if len(pathEdges) > 0 {
+		fmt.Fprintf(&b, "  linkStyle %s stroke:#e00,stroke-width:3px\n", strings.Join(pathEdges, ","))
+	}
+	return b.String()
+}
+
+// graphID makes a node ID that is unique across kinds and safe in both formats.
+func graphID(kind, id string) string {
+	var b strings.Builder
+	b.WriteString(kind + "_")
+	for _, r := range id {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "\n", " ", "|", "#124;").Replace(s)
+}
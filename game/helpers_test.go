@@ -0,0 +1,43 @@
+package game
+
+import "testing"
+
+// testScenario is a small two-room escape: answer the riddle on the cell door,
+// walk into the hall and say the password at the gate.
+func testScenario() *Scenario {
+	return &Scenario{
+		SchemaVersion: SchemaVersion,
+		Theme:         "Test Cell",
+		Rooms: []Room{
+			{ID: "cell", Name: "Cell", Description: "A bare cell.", Items: []string{"spoon"}, Puzzles: []string{"door"}, Exits: []string{"hall"}},
+			{ID: "hall", Name: "Hall", Description: "A long hall.", Puzzles: []string{"gate"}, Exits: []string{"cell"}},
+		},
+		Items: []Item{
+			{ID: "spoon", Name: "Spoon", Description: "A bent spoon."},
+		},
+		Puzzles: []Puzzle{
+			{ID: "door", Name: "Door", Description: "The door asks a riddle.", Solution: "open", Reward: "The door swings open."},
+			{ID: "gate", Name: "Gate", Description: "The gate wants a password.", Solution: "swordfish", Reward: "The gate lifts."},
+		},
+		Hints: map[string]string{"cell": "Talk to the door."},
+		ProgressiveHints: []ProgressiveHint{
+			{Context: "door", Triggers: []HintTrigger{{Type: "failed_attempts", Threshold: 1}}, HintText: "The answer is a kind of door.", Priority: 1},
+			{Context: "cell", Triggers: []HintTrigger{{Type: "commands_tried", Threshold: 1}}, HintText: "The door looks chatty.", Priority: 2},
+		},
+	}
+}
+
+// play runs commands and fails the test on the first error.
+func play(t testing.TB, engine *Engine, commands ...string) {
+	t.Helper()
+	for _, command := range commands {
+		if _, err := engine.ProcessCommand(command); err != nil {
+			t.Fatalf("%q: %v", command, err)
+		}
+	}
+}
+
+// lastMessage returns what the engine said last.
+func lastMessage(engine *Engine) string {
+	return engine.GetState().LastResult
+}
@@ -0,0 +1,19 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHintGivesHighestPriorityFirst(t *testing.T) {
+	engine := NewEngine(testScenario())
+	play(t, engine, "hint", "hint", "hint")
+
+	want := []string{"The door looks chatty.", "The answer is a kind of door."}
+	if got := engine.HintsGiven(); !reflect.DeepEqual(got, want) {
+		t.Errorf("hints given = %q, want %q", got, want)
+	}
+	if got := lastMessage(engine); got != "That's all the help there is here: The answer is a kind of door." {
+		t.Errorf("third hint = %q, want the most specific one repeated", got)
+	}
+}
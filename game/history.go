@@ -0,0 +1,110 @@
+package game
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+const MaxUndoHistory = 50
+
+// pushHistory records the world before a command so it can be undone.
+func (e *Engine) pushHistory() {
+	e.history = append(e.history, e.state.WorldState.clone())
+	if len(e.history) > MaxUndoHistory {
+		e.history = e.history[len(e.history)-MaxUndoHistory:]
+	}
+}
+
+// dropUnchangedHistory discards the last history entry if the command left the
+// world untouched. History entries are clones, so the world is compared as a
+// clone too, and an empty list or map counts the same as a missing one.
+func (e *Engine) dropUnchangedHistory() {
+	if len(e.history) == 0 {
+		return
+	}
+	if reflect.DeepEqual(e.history[len(e.history)-1], e.state.WorldState.clone()) {
+		e.history = e.history[:len(e.history)-1]
+	}
+}
+
+func (e *Engine) CanUndo() bool {
+	return len(e.history) > 0
+}
+
+// handleUndo rewinds the world by one or more steps. Moves, failed attempts
+// and the clock are kept, so undoing never buys back time.
+func (e *Engine) handleUndo(args []string) error {
+	steps := 1
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			e.say("Undo how many steps?")
+			return nil
+		}
+		steps = n
+	}
+
+	if len(e.history) == 0 {
+		e.say("There is nothing to undo.")
+		return nil
+	}
+
+	if steps > len(e.history) {
+		steps = len(e.history)
+	}
+
+	e.state.WorldState = e.history[len(e.history)-steps]
+	e.history = e.history[:len(e.history)-steps]
+	e.state.Undos += steps
+
+	room, _ := e.GetCurrentRoom()
+	message := fmt.Sprintf("You retrace your last step. You are in %s.", room.Name)
+	if steps > 1 {
+		message = fmt.Sprintf("You retrace your last %d steps. You are in %s.", steps, room.Name)
+	}
+	e.emit(Event{Type: EventUndone, Target: room.ID, Message: message})
+	return nil
+}
+
+func (e *Engine) HasCheckpoint() bool {
+	return e.checkpoint != nil
+}
+
+// saveDueCheckpoint saves the checkpoint a command asked for by entering a room
+// or solving a puzzle, once the timer and fail conditions have had their say.
+// A command that loses the game never becomes a checkpoint, or restarting from
+// it would only lose again.
+func (e *Engine) saveDueCheckpoint() {
+	due := e.checkpointDue
+	e.checkpointDue = false
+	if due && !e.state.GameLost {
+		e.saveCheckpoint()
+	}
+}
+
+func (e *Engine) saveCheckpoint() {
+	e.checkpoint = e.state.clone()
+	e.checkpoint.Elapsed = e.Elapsed()
+}
+
+// RestartFromCheckpoint rewinds the game to the last room entered or puzzle solved.
+func (e *Engine) RestartFromCheckpoint() error {
+	if e.checkpoint == nil {
+		return fmt.Errorf("no checkpoint available")
+	}
+	e.state = e.checkpoint.clone()
+	e.history = nil
+	e.clockStarted = time.Now()
+	return nil
+}
+
+// Restart rewinds the game to its initial state.
+func (e *Engine) Restart() error {
+	e.state = newGameState(e.state.Scenario)
+	e.history = nil
+	e.checkpoint = nil
+	e.clockStarted = time.Now()
+	return nil
+}
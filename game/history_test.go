@@ -0,0 +1,96 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUndo(t *testing.T) {
+	engine := NewEngine(testScenario())
+	play(t, engine, "take spoon", "go hall", "undo 2")
+
+	if engine.GetState().CurrentRoom != "cell" || engine.HasItem("spoon") {
+		t.Errorf("after undo: in %s holding spoon %v, want the start", engine.GetState().CurrentRoom, engine.HasItem("spoon"))
+	}
+	if engine.GetState().Moves != 2 {
+		t.Errorf("moves = %d, undo shouldn't give moves back", engine.GetState().Moves)
+	}
+	if engine.CanUndo() {
+		t.Error("expected nothing left to undo")
+	}
+}
+
+func TestCheckpointNotSavedOnLosingMove(t *testing.T) {
+	scenario := testScenario()
+	scenario.Timer = &Timer{Mode: TimerModeTurns, Limit: 3}
+	engine := NewEngine(scenario)
+	play(t, engine, "go hall", "go cell", "go hall")
+
+	if !engine.IsGameLost() {
+		t.Fatal("expected to run out of moves")
+	}
+	if err := engine.RestartFromCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	state := engine.GetState()
+	if state.GameLost || state.CurrentRoom != "cell" || state.Moves != 2 {
+		t.Fatalf("restarted lost=%v in %s after %d moves, want the cell after 2 moves", state.GameLost, state.CurrentRoom, state.Moves)
+	}
+	play(t, engine, "look")
+	if engine.GetState().Moves != 3 {
+		t.Errorf("moves = %d after restarting, want 3", engine.GetState().Moves)
+	}
+}
+
+func TestCheckpointNotSavedOnTrap(t *testing.T) {
+	scenario := testScenario()
+	scenario.Actions = []Action{{ID: "trap", Trigger: ActionTrigger{Type: "solve", Target: "door"}, Message: "The floor gives way!"}}
+	scenario.FailConditions = []FailCondition{{ID: "fell", Type: FailActionTriggered, Target: "trap", Message: "You fall."}}
+	engine := NewEngine(scenario)
+	play(t, engine, "go hall", "go cell", "solve open")
+
+	if !engine.IsGameLost() {
+		t.Fatal("expected the trap to end the game")
+	}
+	if err := engine.RestartFromCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	play(t, engine, "look")
+	if engine.IsGameLost() || engine.IsPuzzleSolved("door") {
+		t.Errorf("restarted game lost=%v, door solved=%v; want the checkpoint from before the trap", engine.IsGameLost(), engine.IsPuzzleSolved("door"))
+	}
+}
+
+func TestOldSaveKeepsNoOpsOutOfHistory(t *testing.T) {
+	scenario := testScenario()
+	scenario.Items = append(scenario.Items, Item{ID: "pin", Name: "Pin", Description: "A hair pin.", Hidden: true})
+	scenario.Rooms[0].Items = append(scenario.Rooms[0].Items, "pin")
+	scenario.Rooms[1].Locked = true
+
+	// Saves from before the world state had no maps, and kept what was hidden
+	// or locked in the scenario
+	state := NewEngine(scenario).GetState().clone()
+	state.WorldState = WorldState{CurrentRoom: "cell"}
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine, err := LoadEngine(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	play(t, engine, "look", "inventory")
+	if engine.CanUndo() {
+		t.Error("looking around went into the undo history")
+	}
+	if !engine.GetState().IsItemHidden("pin") || !engine.GetState().IsRoomLocked("hall") {
+		t.Error("the old save lost what was hidden and locked")
+	}
+
+	engine.state.Inventory = nil
+	play(t, engine, "look")
+	if engine.CanUndo() {
+		t.Error("an empty inventory counted as a change")
+	}
+}
@@ -0,0 +1,93 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SchemaVersion is the scenario format this engine writes. Scenarios without a
+// schema_version are treated as version 0.
+const SchemaVersion = 1
+
+// migrations[i] upgrades a scenario from version i to version i+1.
+var migrations = []func(s *Scenario){
+	migrateActionSystem,
+}
+
+// MigrateScenario upgrades a scenario loaded from an older format in place.
+func MigrateScenario(s *Scenario) error {
+	if s.SchemaVersion > SchemaVersion {
+		return fmt.Errorf("scenario schema version %d is newer than this game supports (%d)", s.SchemaVersion, SchemaVersion)
+	}
+	if s.SchemaVersion < 0 {
+		return fmt.Errorf("invalid scenario schema version %d", s.SchemaVersion)
+	}
+
+	for s.SchemaVersion < SchemaVersion {
+		migrations[s.SchemaVersion](s)
+		s.SchemaVersion++
+	}
+	return nil
+}
+
+// migrateActionSystem turns revealed_by references into actions and the
+// per-room hints into progressive hints, for scenarios written before both existed.
+func migrateActionSystem(s *Scenario) {
+	actions := make(map[string]bool)
+	for _, action := range s.Actions {
+		actions[action.ID] = true
+	}
+	created := make(map[string]int)
+
+	for i := range s.Items {
+		item := &s.Items[i]
+		if !item.Hidden || item.RevealedBy == "" || actions[item.RevealedBy] {
+			continue
+		}
+
+		// Older scenarios named either the item to examine or the action, e.g. "examine_desk"
+		trigger := ActionTrigger{Type: "examine", Target: item.RevealedBy}
+		if verb, target, found := strings.Cut(item.RevealedBy, "_"); found && target != "" && (verb == "examine" || verb == "use" || verb == "take") {
+			if _, err := s.GetItem(item.RevealedBy); err != nil {
+				trigger = ActionTrigger{Type: verb, Target: target}
+			}
+		}
+
+		id := item.RevealedBy
+		if _, err := s.GetItem(id); err == nil {
+			id = "reveal_" + item.ID
+		}
+
+		reveal := ActionEffect{Type: "reveal_item", Target: item.ID}
+		if index, exists := created[id]; exists {
+			// Several items revealed by the same thing share one action
+			s.Actions[index].Effects = append(s.Actions[index].Effects, reveal)
+			s.Actions[index].Message = "You discover something."
+		} else {
+			created[id] = len(s.Actions)
+			s.Actions = append(s.Actions, Action{
+				ID:          id,
+				Trigger:     trigger,
+				Effects:     []ActionEffect{reveal},
+				Message:     fmt.Sprintf("You discover the %s.", item.Name),
+				OneTimeOnly: true,
+			})
+		}
+		item.RevealedBy = id
+	}
+
+	if len(s.ProgressiveHints) == 0 {
+		for _, room := range s.Rooms {
+			hint, exists := s.Hints[room.ID]
+			if !exists || hint == "" {
+				continue
+			}
+			s.ProgressiveHints = append(s.ProgressiveHints, ProgressiveHint{
+				Context:  room.ID,
+				Triggers: []HintTrigger{{Type: "commands_tried", Threshold: 8}},
+				HintText: hint,
+				Priority: 1,
+			})
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+// versionZeroScenario is written the way scenarios were before schema versions:
+// hidden items name what reveals them, and rooms only have plain hints.
+func versionZeroScenario() *Scenario {
+	return &Scenario{
+		Theme: "Old Study",
+		Rooms: []Room{
+			{ID: "study", Name: "Study", Description: "A dusty study.", Items: []string{"desk", "key", "note", "pen"}, Puzzles: []string{"safe"}},
+		},
+		Items: []Item{
+			{ID: "desk", Name: "Desk", Description: "An oak desk."},
+			{ID: "key", Name: "Key", Description: "A brass key.", Hidden: true, RevealedBy: "desk"},
+			{ID: "note", Name: "Note", Description: "A folded note.", Hidden: true, RevealedBy: "examine_drawer"},
+			{ID: "pen", Name: "Pen", Description: "A fountain pen.", Hidden: true, RevealedBy: "examine_drawer"},
+		},
+		Puzzles: []Puzzle{{ID: "safe", Name: "Safe", Description: "A wall safe.", Solution: "1234", RequiredItems: []string{"key"}}},
+		Hints:   map[string]string{"study": "The desk has seen things."},
+	}
+}
+
+func TestMigrateScenarioFromVersionZero(t *testing.T) {
+	if len(migrations) != SchemaVersion {
+		t.Fatalf("%d migrations for schema version %d", len(migrations), SchemaVersion)
+	}
+
+	s := versionZeroScenario()
+	if err := MigrateScenario(s); err != nil {
+		t.Fatal(err)
+	}
+	if s.SchemaVersion != SchemaVersion {
+		t.Errorf("migrated to version %d, want %d", s.SchemaVersion, SchemaVersion)
+	}
+
+	want := []Action{
+		{
+			ID:          "reveal_key",
+			Trigger:     ActionTrigger{Type: "examine", Target: "desk"},
+			Effects:     []ActionEffect{{Type: "reveal_item", Target: "key"}},
+			Message:     "You discover the Key.",
+			OneTimeOnly: true,
+		},
+		{
+			ID:          "examine_drawer",
+			Trigger:     ActionTrigger{Type: "examine", Target: "drawer"},
+			Effects:     []ActionEffect{{Type: "reveal_item", Target: "note"}, {Type: "reveal_item", Target: "pen"}},
+			Message:     "You discover something.",
+			OneTimeOnly: true,
+		},
+	}
+	if !reflect.DeepEqual(s.Actions, want) {
+		t.Errorf("actions = %+v, want %+v", s.Actions, want)
+	}
+	if key, _ := s.GetItem("key"); key.RevealedBy != "reveal_key" {
+		t.Errorf("key is revealed by %q, want the new action", key.RevealedBy)
+	}
+
+	wantHints := []ProgressiveHint{{
+		Context:  "study",
+		Triggers: []HintTrigger{{Type: "commands_tried", Threshold: 8}},
+		HintText: "The desk has seen things.",
+		Priority: 1,
+	}}
+	if !reflect.DeepEqual(s.ProgressiveHints, wantHints) {
+		t.Errorf("progressive hints = %+v, want %+v", s.ProgressiveHints, wantHints)
+	}
+	if problems := s.Validate(); len(problems) != 0 {
+		t.Errorf("migrated scenario has problems: %v", problems)
+	}
+
+	engine := NewEngine(s)
+	play(t, engine, "examine desk")
+	if engine.GetState().IsItemHidden("key") {
+		t.Error("examining the desk didn't reveal the key")
+	}
+}
+
+func TestMigrateScenarioKeepsExistingActionsAndHints(t *testing.T) {
+	s := versionZeroScenario()
+	s.Actions = []Action{{ID: "desk", Trigger: ActionTrigger{Type: "use", Target: "desk"}, Effects: []ActionEffect{{Type: "reveal_item", Target: "key"}}}}
+	s.ProgressiveHints = []ProgressiveHint{{Context: "safe", HintText: "Four digits.", Priority: 1}}
+
+	if err := MigrateScenario(s); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Actions) != 2 || s.Actions[0].ID != "desk" || s.Actions[1].ID != "examine_drawer" {
+		t.Errorf("actions = %+v, want the existing desk action kept and only the drawer added", s.Actions)
+	}
+	if len(s.ProgressiveHints) != 1 {
+		t.Errorf("progressive hints = %+v, want the room hint left alone", s.ProgressiveHints)
+	}
+}
+
+func TestMigrateScenarioLeavesCurrentVersionAlone(t *testing.T) {
+	s := testScenario()
+	s.Items[0].Hidden, s.Items[0].RevealedBy = true, "look_around"
+	before, _ := s.ToJSON()
+	if err := MigrateScenario(s); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := s.ToJSON(); string(after) != string(before) {
+		t.Errorf("migrated a scenario already at version %d:\n%s", SchemaVersion, after)
+	}
+}
+
+func TestMigrateScenarioRejectsUnknownVersions(t *testing.T) {
+	for _, version := range []int{SchemaVersion + 1, -1} {
+		s := testScenario()
+		s.SchemaVersion = version
+		if err := MigrateScenario(s); err == nil {
+			t.Errorf("version %d: migrated without an error", version)
+		}
+	}
+}
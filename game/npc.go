@@ -0,0 +1,307 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+type NPC struct {
+	ID              string          `json:"id"`
+	Name            string          `json:"name"`
+	Description     string          `json:"description"`
+	Greeting        string          `json:"greeting"`
+	DefaultResponse string          `json:"default_response,omitempty"` // reply to topics the NPC knows nothing about
+	Topics          []DialogueTopic `json:"topics"`
+	Trades          []Trade         `json:"trades,omitempty"`
+	Persona         string          `json:"persona,omitempty"`   // set to have replies voiced by the LLM
+	Knowledge       []NPCFact       `json:"knowledge,omitempty"` // everything a voiced NPC may talk about
+}
+
+// NPCFact is something an NPC knows. It is only shared with the LLM once its conditions are met.
+type NPCFact struct {
+	ID         string            `json:"id"`
+	Fact       string            `json:"fact"`
+	Conditions []ActionCondition `json:"conditions,omitempty"`
+}
+
+type DialogueRequest struct {
+	Scenario     *Scenario
+	NPC          *NPC
+	Question     string
+	Facts        []string
+	AuthoredLine string // the scripted reply, if any, for the LLM to stay consistent with
+}
+
+// DialogueVoice generates in-character replies for NPCs that have a persona.
+type DialogueVoice interface {
+	VoiceNPC(req DialogueRequest) (string, error)
+}
+
+// DialogueTopic is one node of an NPC's dialogue tree. Follow-up topics are
+// gated with a "topic_discussed" condition on their parent.
+type DialogueTopic struct {
+	ID          string            `json:"id"`
+	Keywords    []string          `json:"keywords"` // words the player can ask about
+	Conditions  []ActionCondition `json:"conditions,omitempty"`
+	Effects     []ActionEffect    `json:"effects,omitempty"`
+	Response    string            `json:"response"`
+	OneTimeOnly bool              `json:"one_time_only,omitempty"`
+}
+
+type Trade struct {
+	Wants      string            `json:"wants"`           // item ID the player hands over
+	Gives      string            `json:"gives,omitempty"` // item ID the player receives
+	Conditions []ActionCondition `json:"conditions,omitempty"`
+	Effects    []ActionEffect    `json:"effects,omitempty"`
+	Response   string            `json:"response"`
+}
+
+func (s *Scenario) GetNPC(id string) (*NPC, error) {
+	for i := range s.NPCs {
+		if s.NPCs[i].ID == id {
+			return &s.NPCs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("npc %s not found", id)
+}
+
+func topicKey(npcID, topicID string) string {
+	return npcID + "." + topicID
+}
+
+func (e *Engine) HasDiscussedTopic(npcID, topicID string) bool {
+	key := topicKey(npcID, topicID)
+	for _, discussed := range e.state.DiscussedTopics {
+		if discussed == key {
+			return true
+		}
+	}
+	return false
+}
+
+// findNPC looks up an NPC in the current room by (partial) name.
+func (e *Engine) findNPC(name string) *NPC {
+	room, err := e.GetCurrentRoom()
+	if err != nil || name == "" {
+		return nil
+	}
+
+	for _, npcID := range room.NPCs {
+		npc, err := e.state.Scenario.GetNPC(npcID)
+		if err != nil {
+			continue
+		}
+		if strings.Contains(strings.ToLower(npc.Name), name) || strings.Contains(strings.ToLower(npc.ID), name) {
+			return npc
+		}
+	}
+	return nil
+}
+
+// availableTopics returns the topics whose conditions are met and that can still be discussed.
+func (e *Engine) availableTopics(npc *NPC) []DialogueTopic {
+	var topics []DialogueTopic
+	for _, topic := range npc.Topics {
+		if topic.OneTimeOnly && e.HasDiscussedTopic(npc.ID, topic.ID) {
+			continue
+		}
+		if !e.checkActionConditions(topic.Conditions) {
+			continue
+		}
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+func (e *Engine) handleTalk(args []string) error {
+	if len(args) > 0 && (args[0] == "to" || args[0] == "with") {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		e.say("Talk to whom?")
+		return nil
+	}
+
+	npc := e.findNPC(strings.Join(args, " "))
+	if npc == nil {
+		e.say("There's nobody like that here.")
+		return nil
+	}
+
+	message := npc.Greeting
+	if message == "" {
+		message = fmt.Sprintf("%s regards you silently.", npc.Name)
+	}
+
+	var undiscussed []string
+	for _, topic := range e.availableTopics(npc) {
+		if !e.HasDiscussedTopic(npc.ID, topic.ID) && len(topic.Keywords) > 0 {
+			undiscussed = append(undiscussed, topic.Keywords[0])
+		}
+	}
+	if len(undiscussed) > 0 {
+		message += fmt.Sprintf(" (You could ask about: %s)", strings.Join(undiscussed, ", "))
+	}
+
+	e.emit(Event{Type: EventDialogue, Target: npc.ID, Message: message})
+	return nil
+}
+
+func (e *Engine) handleAsk(args []string) error {
+	text := strings.Join(args, " ")
+	parts := strings.SplitN(text, " about ", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+		e.say("Ask whom about what?")
+		return nil
+	}
+
+	npc := e.findNPC(strings.TrimSpace(parts[0]))
+	if npc == nil {
+		e.say("There's nobody like that here.")
+		return nil
+	}
+
+	subject := strings.TrimSpace(parts[1])
+	for _, topic := range e.availableTopics(npc) {
+		if !matchesKeyword(topic.Keywords, subject) {
+			continue
+		}
+
+		if !e.HasDiscussedTopic(npc.ID, topic.ID) {
+			e.state.DiscussedTopics = append(e.state.DiscussedTopics, topicKey(npc.ID, topic.ID))
+		}
+		e.emit(Event{Type: EventDialogue, Target: npc.ID, Message: e.voiceNPC(npc, subject, topic.Response)})
+		e.executeActionEffects(topic.Effects)
+		return nil
+	}
+
+	// Voiced NPCs can still answer off-script questions from what they know
+	response := e.voiceNPC(npc, subject, "")
+	if response == "" {
+		response = npc.DefaultResponse
+	}
+	if response == "" {
+		response = fmt.Sprintf("%s doesn't seem to know anything about that.", npc.Name)
+	}
+	e.emit(Event{Type: EventDialogue, Target: npc.ID, Message: response})
+	return nil
+}
+
+func (e *Engine) SetDialogueVoice(voice DialogueVoice) {
+	e.voice = voice
+}
+
+// RevealableFacts returns what the NPC is currently allowed to talk about.
+func (e *Engine) RevealableFacts(npc *NPC) []string {
+	var facts []string
+	for _, fact := range npc.Knowledge {
+		if e.checkActionConditions(fact.Conditions) {
+			facts = append(facts, fact.Fact)
+		}
+	}
+	return facts
+}
+
+// voiceNPC asks the dialogue voice for a reply, falling back to the authored
+// line when there is no voice, the NPC has no persona, or the reply is unsafe.
+func (e *Engine) voiceNPC(npc *NPC, question, authored string) string {
+	if e.voice == nil || npc.Persona == "" {
+		return authored
+	}
+
+	facts := e.RevealableFacts(npc)
+	reply, err := e.voice.VoiceNPC(DialogueRequest{
+		Scenario:     e.state.Scenario,
+		NPC:          npc,
+		Question:     question,
+		Facts:        facts,
+		AuthoredLine: authored,
+	})
+	if err != nil || strings.TrimSpace(reply) == "" {
+		return authored
+	}
+
+	if e.leaksSolution(reply, append(facts, authored)) {
+		return authored
+	}
+	return strings.TrimSpace(reply)
+}
+
+// leaksSolution reports whether text gives away an answer to an unsolved
+// puzzle that none of the allowed lines already reveal.
+func (e *Engine) leaksSolution(text string, allowed []string) bool {
+	for _, puzzle := range e.state.Scenario.Puzzles {
+		if e.IsPuzzleSolved(puzzle.ID) || !puzzle.revealedIn(text) {
+			continue
+		}
+
+		revealed := false
+		for _, line := range allowed {
+			if puzzle.revealedIn(line) {
+				revealed = true
+				break
+			}
+		}
+		if !revealed {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Engine) handleGive(args []string) error {
+	text := strings.Join(args, " ")
+	parts := strings.SplitN(text, " to ", 2)
+	if len(parts) != 2 {
+		e.say("Give what to whom?")
+		return nil
+	}
+
+	itemName := strings.TrimSpace(parts[0])
+	var itemID string
+	for _, id := range e.state.Inventory {
+		item, err := e.state.Scenario.GetItem(id)
+		if err == nil && strings.Contains(strings.ToLower(item.Name), itemName) {
+			itemID = id
+			break
+		}
+	}
+	if itemID == "" {
+		e.say(fmt.Sprintf("You don't have %s.", itemName))
+		return nil
+	}
+
+	npc := e.findNPC(strings.TrimSpace(parts[1]))
+	if npc == nil {
+		e.say("There's nobody like that here.")
+		return nil
+	}
+
+	for _, trade := range npc.Trades {
+		if trade.Wants != itemID || !e.checkActionConditions(trade.Conditions) {
+			continue
+		}
+
+		e.removeFromInventory(itemID)
+		e.emit(Event{Type: EventItemGiven, Target: itemID, Message: trade.Response})
+		if trade.Gives != "" && !e.HasItem(trade.Gives) {
+			e.state.Inventory = append(e.state.Inventory, trade.Gives)
+			e.emit(Event{Type: EventItemAdded, Target: trade.Gives})
+		}
+		e.executeActionEffects(trade.Effects)
+		return nil
+	}
+
+	e.emit(Event{Type: EventDialogue, Target: npc.ID, Message: fmt.Sprintf("%s doesn't want that.", npc.Name)})
+	return nil
+}
+
+func matchesKeyword(keywords []string, subject string) bool {
+	for _, keyword := range keywords {
+		keyword = strings.ToLower(keyword)
+		if strings.Contains(subject, keyword) || strings.Contains(keyword, subject) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package game
+
+import "testing"
+
+func TestLeaksSolution(t *testing.T) {
+	scenario := testScenario()
+	scenario.Puzzles = append(scenario.Puzzles,
+		Puzzle{ID: "clock", Type: PuzzleTypeRiddle, Solution: "midday", Answers: []string{"noon", "12"}},
+		Puzzle{ID: "safe", Type: PuzzleTypeCombination, Solution: "4-7-2"},
+		Puzzle{ID: "pad", Type: PuzzleTypeKeypad, Solution: "9*1#"},
+		Puzzle{ID: "note", Type: PuzzleTypeCipher, Solution: "a cat", Cipher: &Cipher{Method: CipherCaesar, Shift: 3}},
+		Puzzle{ID: "banners", Type: PuzzleTypeOrdering, Solution: "red, green, blue"},
+		Puzzle{ID: "levers", Type: PuzzleTypeSequence, Steps: []PuzzleStep{{Action: "pull red lever"}, {Action: "pull blue lever"}}},
+	)
+	engine := NewEngine(scenario)
+
+	tests := []struct {
+		text string
+		leak bool
+	}{
+		{"The password? It's swordfish, of course.", true},
+		{"Come back at noon.", true},
+		{"The clock strikes 12.", true},
+		{"Midday, they say.", true},
+		{"I dialled 4 7 2 once.", true},
+		{"Try 472.", true},
+		{"My number is 4721.", false},
+		{"Press 9, then 1.", true},
+		{"It says A-CAT.", true},
+		{"An education costs nothing.", false},
+		{"Red, then green and then blue.", true},
+		{"Red and blue are my favourites.", false},
+		{"Pull the red lever.", true},
+		{"What a lovely evening.", false},
+	}
+	for _, test := range tests {
+		if leak := engine.leaksSolution(test.text, nil); leak != test.leak {
+			t.Errorf("leaksSolution(%q) = %v, want %v", test.text, leak, test.leak)
+		}
+	}
+}
+
+func TestLeaksSolutionAllowsRevealedFacts(t *testing.T) {
+	engine := NewEngine(testScenario())
+	text := "As I told you, the gate wants swordfish."
+
+	if engine.leaksSolution(text, []string{"The password is SWORDFISH."}) {
+		t.Error("an answer the NPC may already give shouldn't count as a leak")
+	}
+
+	play(t, engine, "solve open", "go hall", "solve swordfish")
+	if engine.leaksSolution(text, nil) {
+		t.Error("answers to solved puzzles shouldn't count as a leak")
+	}
+}
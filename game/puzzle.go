@@ -0,0 +1,578 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	PuzzleTypeAnswer      = "answer"
+	PuzzleTypeSequence    = "sequence"
+	PuzzleTypeCombination = "combination"
+	PuzzleTypeKeypad      = "keypad"
+	PuzzleTypeCipher      = "cipher"
+	PuzzleTypeRiddle      = "riddle"
+	PuzzleTypeOrdering    = "ordering"
+)
+
+const (
+	CipherCaesar       = "caesar"
+	CipherSubstitution = "substitution"
+)
+
+// Cipher describes how a cipher puzzle's Solution is encrypted for the player to decode.
+type Cipher struct {
+	Method string `json:"method"`          // "caesar" or "substitution"
+	Shift  int    `json:"shift,omitempty"` // caesar shift
+	Key    string `json:"key,omitempty"`   // substitution alphabet: the letters a-z map to, in order
+}
+
+// PuzzleStep is one interaction of a sequence puzzle, written as the command
+// the player types, e.g. "pull red lever" or "turn dial to 3".
+type PuzzleStep struct {
+	Action   string `json:"action"`
+	Feedback string `json:"feedback"`
+}
+
+// normalizeCommand lowercases a command and drops articles so "pull the red lever"
+// matches a step written as "pull red lever".
+func normalizeCommand(words []string) string {
+	var kept []string
+	for _, word := range words {
+		word = strings.ToLower(word)
+		if word == "the" || word == "a" || word == "an" {
+			continue
+		}
+		kept = append(kept, word)
+	}
+	return strings.Join(kept, " ")
+}
+
+func (e *Engine) GetPuzzleProgress(puzzleID string) int {
+	return e.state.PuzzleProgress[puzzleID]
+}
+
+// handlePuzzleStep advances a sequence puzzle in the current room if the command
+// is one of its steps. It reports whether the command was consumed.
+func (e *Engine) handlePuzzleStep(words []string) bool {
+	command := normalizeCommand(words)
+	room, err := e.GetCurrentRoom()
+	if err != nil {
+		return false
+	}
+
+	for _, puzzleID := range room.Puzzles {
+		puzzle, err := e.state.Scenario.GetPuzzle(puzzleID)
+		if err != nil || puzzle.Type != PuzzleTypeSequence || e.IsPuzzleSolved(puzzleID) {
+			continue
+		}
+
+		matches := func(step PuzzleStep) bool {
+			return normalizeCommand(strings.Fields(step.Action)) == command
+		}
+
+		// Steps can repeat, so the next step is checked first; any other
+		// step is a mistake
+		progress := e.state.PuzzleProgress[puzzleID]
+		next := progress < len(puzzle.Steps) && matches(puzzle.Steps[progress])
+		if !next {
+			isStep := false
+			for _, step := range puzzle.Steps {
+				if matches(step) {
+					isStep = true
+					break
+				}
+			}
+			if !isStep {
+				continue
+			}
+		}
+
+		if !e.hasRequiredItems(puzzle) {
+			e.say("You don't have everything needed to solve this puzzle.")
+			return true
+		}
+
+		if !next {
+			e.puzzleMistake(puzzle)
+			return true
+		}
+
+		feedback := puzzle.Steps[progress].Feedback
+		progress++
+		e.state.PuzzleProgress[puzzleID] = progress
+		if feedback == "" {
+			feedback = fmt.Sprintf("Something shifts. (%d/%d)", progress, len(puzzle.Steps))
+		}
+		e.emit(Event{Type: EventPuzzleProgress, Target: puzzleID, Message: feedback})
+
+		if progress >= len(puzzle.Steps) {
+			delete(e.state.PuzzleProgress, puzzleID)
+			e.solvePuzzle(puzzle)
+		}
+		return true
+	}
+
+	return false
+}
+
+func (e *Engine) puzzleMistake(puzzle *Puzzle) {
+	e.state.FailedAttempts[puzzle.ID]++
+
+	message := puzzle.MistakeMessage
+	if message == "" {
+		message = "Nothing happens. That doesn't seem to be the right order."
+	}
+	if puzzle.ResetOnMistake && e.state.PuzzleProgress[puzzle.ID] > 0 {
+		delete(e.state.PuzzleProgress, puzzle.ID)
+		if puzzle.MistakeMessage == "" {
+			message = "With a clunk, the mechanism resets. You'll have to start over."
+		}
+	}
+
+	e.emit(Event{Type: EventPuzzleFailed, Target: puzzle.ID, Message: message})
+}
+
+// IsCodeLock reports whether the puzzle is opened with the "enter" command.
+func (p *Puzzle) IsCodeLock() bool {
+	return p.Type == PuzzleTypeCombination || p.Type == PuzzleTypeKeypad
+}
+
+// CheckAnswer validates an attempt according to the puzzle type.
+func (p *Puzzle) CheckAnswer(answer string) bool {
+	switch p.Type {
+	case PuzzleTypeCombination:
+		code := digitsOnly(answer)
+		return code != "" && code == digitsOnly(p.Solution)
+	case PuzzleTypeKeypad:
+		code := keypadKeys(answer)
+		return code != "" && code == keypadKeys(p.Solution)
+	case PuzzleTypeCipher:
+		text := lettersOnly(answer)
+		return text != "" && text == lettersOnly(p.Solution)
+	case PuzzleTypeRiddle:
+		for _, accepted := range append([]string{p.Solution}, p.Answers...) {
+			if normalizeAnswer(answer) == normalizeAnswer(accepted) {
+				return true
+			}
+		}
+		return false
+	case PuzzleTypeOrdering:
+		expected := splitOrder(p.Solution)
+		return len(expected) > 0 && len(splitOrder(answer)) == len(expected) && p.orderingMatches(answer) == len(expected)
+	default:
+		return normalizeAnswer(answer) == normalizeAnswer(p.Solution)
+	}
+}
+
+// solutionKey is the Solution as CheckAnswer compares it. If it's empty, no
+// answer could ever be right, or any answer would be.
+func (p *Puzzle) solutionKey() string {
+	switch p.Type {
+	case PuzzleTypeCombination:
+		return digitsOnly(p.Solution)
+	case PuzzleTypeKeypad:
+		return keypadKeys(p.Solution)
+	case PuzzleTypeCipher:
+		return lettersOnly(p.Solution)
+	case PuzzleTypeOrdering:
+		return strings.Join(splitOrder(p.Solution), ",")
+	default:
+		return normalizeAnswer(p.Solution)
+	}
+}
+
+// acceptedForms lists every answer CheckAnswer would accept, each normalized
+// the way CheckAnswer compares it and with the spaces taken out.
+func (p *Puzzle) acceptedForms() []string {
+	compact := func(s string) string { return strings.ReplaceAll(normalizeAnswer(s), " ", "") }
+
+	switch p.Type {
+	case PuzzleTypeCombination:
+		return []string{digitsOnly(p.Solution)}
+	case PuzzleTypeKeypad:
+		return []string{strings.NewReplacer("*", "", "#", "").Replace(keypadKeys(p.Solution))}
+	case PuzzleTypeCipher:
+		return []string{lettersOnly(p.Solution)}
+	case PuzzleTypeRiddle:
+		var forms []string
+		for _, accepted := range append([]string{p.Solution}, p.Answers...) {
+			forms = append(forms, compact(accepted))
+		}
+		return forms
+	case PuzzleTypeOrdering:
+		return []string{strings.Join(splitOrder(p.Solution), "")}
+	case PuzzleTypeSequence:
+		var forms []string
+		for _, step := range p.Steps {
+			forms = append(forms, compact(step.Action))
+		}
+		return forms
+	default:
+		return []string{compact(p.Solution)}
+	}
+}
+
+// revealedIn reports whether text spells out any accepted answer, however it's
+// spaced or punctuated: "4-7-2" and "4 7 2" both give away a code of 472, but
+// "4721" doesn't.
+func (p *Puzzle) revealedIn(text string) bool {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	// Skip the words CheckAnswer would ignore, or that only join up a code or an order
+	skip := map[string]bool{"the": true, "a": true, "an": true}
+	switch p.Type {
+	case PuzzleTypeCipher:
+		skip = nil
+	case PuzzleTypeCombination, PuzzleTypeKeypad:
+		skip = map[string]bool{"then": true, "and": true}
+	case PuzzleTypeOrdering:
+		skip["then"], skip["and"] = true, true
+	}
+	var kept []string
+	for _, word := range words {
+		if !skip[word] {
+			kept = append(kept, word)
+		}
+	}
+
+	for _, form := range p.acceptedForms() {
+		if form != "" && spelledOut(kept, form) {
+			return true
+		}
+	}
+	return false
+}
+
+// spelledOut reports whether a run of consecutive words joins up to exactly target.
+func spelledOut(words []string, target string) bool {
+	for i := range words {
+		joined := ""
+		for _, word := range words[i:] {
+			joined += word
+			if joined == target {
+				return true
+			}
+			if !strings.HasPrefix(target, joined) {
+				break
+			}
+		}
+	}
+	return false
+}
+
+// CipherText returns the encrypted Solution shown to the player for cipher puzzles.
+func (p *Puzzle) CipherText() string {
+	if p.Type != PuzzleTypeCipher || p.Cipher == nil {
+		return ""
+	}
+
+	var out strings.Builder
+	for _, r := range strings.ToLower(p.Solution) {
+		if r < 'a' || r > 'z' {
+			out.WriteRune(unicode.ToUpper(r))
+			continue
+		}
+		out.WriteRune(unicode.ToUpper(p.Cipher.encode(r)))
+	}
+	return out.String()
+}
+
+func (c *Cipher) encode(r rune) rune {
+	if c.Method == CipherSubstitution && len(c.Key) == 26 {
+		return rune(strings.ToLower(c.Key)[r-'a'])
+	}
+	shift := ((c.Shift % 26) + 26) % 26
+	return 'a' + (r-'a'+rune(shift))%26
+}
+
+// Pieces lists what an ordering puzzle asks the player to arrange, alphabetically
+// so the listing doesn't give the order away.
+func (p *Puzzle) Pieces() []string {
+	pieces := splitOrder(p.Solution)
+	sort.Strings(pieces)
+	return pieces
+}
+
+// orderingMatches counts the pieces the player has put in the right place.
+func (p *Puzzle) orderingMatches(answer string) int {
+	expected := splitOrder(p.Solution)
+	given := splitOrder(answer)
+
+	matches := 0
+	for i := range expected {
+		if i < len(given) && given[i] == expected[i] {
+			matches++
+		}
+	}
+	return matches
+}
+
+// mistakeMessage gives type-specific feedback on a wrong attempt.
+func (p *Puzzle) mistakeMessage(answer string) string {
+	switch p.Type {
+	case PuzzleTypeCombination:
+		return "You dial in the numbers, but the lock doesn't budge."
+	case PuzzleTypeKeypad:
+		return "The keypad flashes red and beeps angrily."
+	case PuzzleTypeCipher:
+		return "You read your translation back, but it doesn't make sense yet."
+	case PuzzleTypeRiddle:
+		return "That doesn't answer the riddle."
+	case PuzzleTypeOrdering:
+		return fmt.Sprintf("Nothing happens. %d of %d are in the right place.", p.orderingMatches(answer), len(splitOrder(p.Solution)))
+	default:
+		return "That's not correct."
+	}
+}
+
+// typeHint returns the built-in hint for the given level of a typed puzzle, or
+// "" once the puzzle type has nothing more to give away.
+func (p *Puzzle) typeHint(level int) string {
+	if level < 1 {
+		return ""
+	}
+
+	switch p.Type {
+	case PuzzleTypeCombination, PuzzleTypeKeypad:
+		code := []rune(digitsOnly(p.Solution))
+		unit := "digit"
+		if p.Type == PuzzleTypeKeypad {
+			code = []rune(keypadKeys(p.Solution))
+			unit = "key"
+		}
+		if level >= len(code) {
+			return ""
+		}
+		if level == 1 {
+			return fmt.Sprintf("The code has %d %ss and starts with %s.", len(code), unit, string(code[0]))
+		}
+		return fmt.Sprintf("The code starts with %s.", strings.Join(strings.Split(string(code[:level]), ""), "-"))
+	case PuzzleTypeCipher:
+		if p.Cipher == nil {
+			return ""
+		}
+		if level == 1 {
+			if p.Cipher.Method == CipherSubstitution {
+				return "Each letter in the message seems to stand for a different letter."
+			}
+			return "Every letter in the message seems to be shifted along the alphabet by the same amount."
+		}
+		if p.Cipher.Method != CipherSubstitution {
+			if level == 2 {
+				return fmt.Sprintf("Try shifting each letter back by %d.", ((p.Cipher.Shift%26)+26)%26)
+			}
+			return ""
+		}
+		letters := distinctLetters(p.Solution)
+		if level-2 >= len(letters)-1 {
+			return ""
+		}
+		plain := letters[level-2]
+		return fmt.Sprintf("In the cipher, '%c' stands for '%c'.", unicode.ToUpper(p.Cipher.encode(plain)), unicode.ToUpper(plain))
+	case PuzzleTypeRiddle:
+		answer := []rune(normalizeAnswer(p.Solution))
+		if len(answer) == 0 {
+			return ""
+		}
+		switch level {
+		case 1:
+			return fmt.Sprintf("The answer has %d letters.", len([]rune(lettersOnly(string(answer)))))
+		case 2:
+			return fmt.Sprintf("The answer starts with '%s'.", strings.ToUpper(string(answer[0])))
+		}
+		return ""
+	case PuzzleTypeOrdering:
+		order := splitOrder(p.Solution)
+		if level >= len(order) {
+			return ""
+		}
+		return fmt.Sprintf("The order starts: %s.", strings.Join(order[:level], ", "))
+	}
+	return ""
+}
+
+// attemptPuzzle checks an answer against a puzzle, solving it or recording the mistake.
+func (e *Engine) attemptPuzzle(puzzle *Puzzle, answer string) {
+	if !e.hasRequiredItems(puzzle) {
+		e.say("You don't have everything needed to solve this puzzle.")
+		return
+	}
+
+	if puzzle.CheckAnswer(answer) {
+		e.solvePuzzle(puzzle)
+		return
+	}
+
+	e.state.FailedAttempts[puzzle.ID]++
+	e.emit(Event{Type: EventPuzzleFailed, Target: puzzle.ID, Message: puzzle.mistakeMessage(answer)})
+
+	// Typed puzzles give away a little more every second mistake
+	failed := e.state.FailedAttempts[puzzle.ID]
+	if failed%2 == 0 {
+		if hint := puzzle.typeHint(failed / 2); hint != "" {
+			e.emit(Event{Type: EventHintUnlocked, Target: puzzle.ID, Message: hint})
+		}
+	}
+}
+
+// findPuzzle returns the first unsolved puzzle in the current room accepted by
+// match, preferring one whose name contains target.
+func (e *Engine) findPuzzle(target string, match func(*Puzzle) bool) *Puzzle {
+	room, err := e.GetCurrentRoom()
+	if err != nil {
+		return nil
+	}
+
+	var first *Puzzle
+	for _, puzzleID := range room.Puzzles {
+		puzzle, err := e.state.Scenario.GetPuzzle(puzzleID)
+		if err != nil || e.IsPuzzleSolved(puzzleID) || !match(puzzle) {
+			continue
+		}
+		if target != "" && strings.Contains(strings.ToLower(puzzle.Name), target) {
+			return puzzle
+		}
+		if first == nil {
+			first = puzzle
+		}
+	}
+	return first
+}
+
+func (e *Engine) handleEnter(args []string) error {
+	if len(args) == 0 {
+		e.say("Enter what?")
+		return nil
+	}
+
+	code, target := strings.Join(args, " "), ""
+	for _, sep := range []string{" on ", " into ", " in "} {
+		if parts := strings.SplitN(code, sep, 2); len(parts) == 2 {
+			code, target = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+			break
+		}
+	}
+
+	puzzle := e.findPuzzle(target, (*Puzzle).IsCodeLock)
+	if puzzle == nil {
+		e.say("There's nothing here to enter a code into.")
+		return nil
+	}
+
+	e.attemptPuzzle(puzzle, code)
+	return nil
+}
+
+func (e *Engine) handleArrange(args []string) error {
+	if len(args) == 0 {
+		e.say("Arrange what, and in which order?")
+		return nil
+	}
+
+	puzzle := e.findPuzzle("", func(p *Puzzle) bool { return p.Type == PuzzleTypeOrdering })
+	if puzzle == nil {
+		e.say("There's nothing here to arrange.")
+		return nil
+	}
+
+	e.attemptPuzzle(puzzle, strings.Join(args, " "))
+	return nil
+}
+
+// describePuzzle is shown when the player looks at a puzzle.
+func (e *Engine) describePuzzle(puzzle *Puzzle) string {
+	description := puzzle.Description
+	switch puzzle.Type {
+	case PuzzleTypeCipher:
+		if text := puzzle.CipherText(); text != "" {
+			description += fmt.Sprintf(" The message reads: %s", text)
+		}
+	case PuzzleTypeOrdering:
+		description += fmt.Sprintf(" You could arrange: %s.", strings.Join(puzzle.Pieces(), ", "))
+	case PuzzleTypeSequence:
+		if progress := e.state.PuzzleProgress[puzzle.ID]; progress > 0 {
+			description += fmt.Sprintf(" You've worked %d of %d steps so far.", progress, len(puzzle.Steps))
+		}
+	}
+	return description
+}
+
+func normalizeAnswer(answer string) string {
+	var cleaned strings.Builder
+	for _, r := range strings.ToLower(answer) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			cleaned.WriteRune(r)
+		} else {
+			cleaned.WriteRune(' ')
+		}
+	}
+	return normalizeCommand(strings.Fields(cleaned.String()))
+}
+
+func digitsOnly(s string) string {
+	var out strings.Builder
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			out.WriteRune(r)
+		}
+	}
+	return out.String()
+}
+
+func lettersOnly(s string) string {
+	var out strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) {
+			out.WriteRune(r)
+		}
+	}
+	return out.String()
+}
+
+func keypadKeys(s string) string {
+	var out strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '*' || r == '#' {
+			out.WriteRune(r)
+		}
+	}
+	return out.String()
+}
+
+func distinctLetters(s string) []rune {
+	var letters []rune
+	seen := make(map[rune]bool)
+	for _, r := range lettersOnly(s) {
+		if r >= 'a' && r <= 'z' && !seen[r] {
+			seen[r] = true
+			letters = append(letters, r)
+		}
+	}
+	return letters
+}
+
+// splitOrder turns "red, green then blue" into its normalized pieces.
+func splitOrder(s string) []string {
+	s = strings.ToLower(s)
+	for _, sep := range []string{" then ", ">", ";"} {
+		s = strings.ReplaceAll(s, sep, ",")
+	}
+
+	parts := strings.Split(s, ",")
+	if len(parts) == 1 {
+		parts = strings.Fields(s)
+	}
+
+	var pieces []string
+	for _, part := range parts {
+		if piece := normalizeAnswer(part); piece != "" {
+			pieces = append(pieces, piece)
+		}
+	}
+	return pieces
+}
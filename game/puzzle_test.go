@@ -0,0 +1,152 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckAnswer(t *testing.T) {
+	tests := []struct {
+		name   string
+		puzzle Puzzle
+		answer string
+		want   bool
+	}{
+		{"answer", Puzzle{Solution: "The Moon"}, "moon", true},
+		{"answer punctuation", Puzzle{Solution: "moon"}, "Moon!", true},
+		{"answer wrong", Puzzle{Solution: "moon"}, "sun", false},
+
+		{"riddle solution", Puzzle{Type: PuzzleTypeRiddle, Solution: "echo", Answers: []string{"an echo", "sound"}}, "Echo", true},
+		{"riddle other answer", Puzzle{Type: PuzzleTypeRiddle, Solution: "echo", Answers: []string{"sound"}}, "a sound", true},
+		{"riddle wrong", Puzzle{Type: PuzzleTypeRiddle, Solution: "echo", Answers: []string{"sound"}}, "wind", false},
+
+		{"combination", Puzzle{Type: PuzzleTypeCombination, Solution: "4-7-2"}, "4 7 2", true},
+		{"combination wrong", Puzzle{Type: PuzzleTypeCombination, Solution: "472"}, "4721", false},
+		{"combination no digits", Puzzle{Type: PuzzleTypeCombination, Solution: "472"}, "open", false},
+
+		{"keypad", Puzzle{Type: PuzzleTypeKeypad, Solution: "12#"}, "1 2 #", true},
+		{"keypad wrong", Puzzle{Type: PuzzleTypeKeypad, Solution: "12#"}, "12*", false},
+
+		{"cipher", Puzzle{Type: PuzzleTypeCipher, Solution: "Meet at noon"}, "meetatnoon", true},
+		{"cipher wrong", Puzzle{Type: PuzzleTypeCipher, Solution: "meet at noon"}, "meet at dawn", false},
+		{"cipher empty answer", Puzzle{Type: PuzzleTypeCipher, Solution: "42"}, "anything", false},
+
+		{"ordering", Puzzle{Type: PuzzleTypeOrdering, Solution: "red, green, blue"}, "red then green then blue", true},
+		{"ordering wrong", Puzzle{Type: PuzzleTypeOrdering, Solution: "red, green, blue"}, "green, red, blue", false},
+		{"ordering extra pieces", Puzzle{Type: PuzzleTypeOrdering, Solution: "red, green, blue"}, "red, green, blue, yellow", false},
+		{"ordering too few", Puzzle{Type: PuzzleTypeOrdering, Solution: "red, green, blue"}, "red, green", false},
+	}
+
+	for _, test := range tests {
+		if got := test.puzzle.CheckAnswer(test.answer); got != test.want {
+			t.Errorf("%s: CheckAnswer(%q) = %v, want %v", test.name, test.answer, got, test.want)
+		}
+	}
+}
+
+func TestValidateRejectsUnanswerableSolutions(t *testing.T) {
+	tests := []struct {
+		name   string
+		puzzle Puzzle
+	}{
+		{"answer of only articles", Puzzle{Solution: "the"}},
+		{"cipher without letters", Puzzle{Type: PuzzleTypeCipher, Solution: "1234", Cipher: &Cipher{Method: CipherCaesar, Shift: 3}}},
+		{"combination without digits", Puzzle{Type: PuzzleTypeCombination, Solution: "open"}},
+		{"ordering without pieces", Puzzle{Type: PuzzleTypeOrdering, Solution: ", ,"}},
+	}
+
+	for _, test := range tests {
+		scenario := testScenario()
+		puzzle := test.puzzle
+		puzzle.ID, puzzle.Name = "door", "Door"
+		scenario.Puzzles[0] = puzzle
+
+		problems := scenario.Validate()
+		found := false
+		for _, problem := range problems {
+			if strings.Contains(problem, `puzzle "door"`) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s: expected a problem with the door puzzle, got %v", test.name, problems)
+		}
+	}
+
+	if problems := testScenario().Validate(); len(problems) != 0 {
+		t.Errorf("test scenario has problems: %v", problems)
+	}
+}
+
+func TestTypeHint(t *testing.T) {
+	tests := []struct {
+		name   string
+		puzzle Puzzle
+		level  int
+		want   string
+	}{
+		{"combination first", Puzzle{Type: PuzzleTypeCombination, Solution: "472"}, 1, "The code has 3 digits and starts with 4."},
+		{"combination more", Puzzle{Type: PuzzleTypeCombination, Solution: "472"}, 2, "The code starts with 4-7."},
+		{"combination all but the last", Puzzle{Type: PuzzleTypeCombination, Solution: "472"}, 3, ""},
+		{"keypad", Puzzle{Type: PuzzleTypeKeypad, Solution: "9#1"}, 2, "The code starts with 9-#."},
+		{"caesar", Puzzle{Type: PuzzleTypeCipher, Solution: "abc", Cipher: &Cipher{Method: CipherCaesar, Shift: -3}}, 2, "Try shifting each letter back by 23."},
+		{"substitution", Puzzle{Type: PuzzleTypeCipher, Solution: "ab", Cipher: &Cipher{Method: CipherSubstitution, Key: "zyxwvutsrqponmlkjihgfedcba"}}, 2, "In the cipher, 'Z' stands for 'A'."},
+		{"riddle length", Puzzle{Type: PuzzleTypeRiddle, Solution: "Échelle"}, 1, "The answer has 7 letters."},
+		{"riddle first letter", Puzzle{Type: PuzzleTypeRiddle, Solution: "échelle"}, 2, "The answer starts with 'É'."},
+		{"ordering", Puzzle{Type: PuzzleTypeOrdering, Solution: "red, green, blue"}, 1, "The order starts: red."},
+		{"plain answer", Puzzle{Solution: "moon"}, 1, ""},
+	}
+
+	for _, test := range tests {
+		if got := test.puzzle.typeHint(test.level); got != test.want {
+			t.Errorf("%s: typeHint(%d) = %q, want %q", test.name, test.level, got, test.want)
+		}
+	}
+}
+
+func TestSequencePuzzle(t *testing.T) {
+	scenario := testScenario()
+	scenario.Puzzles[0] = Puzzle{
+		ID:             "door",
+		Name:           "Door",
+		Description:    "Two levers.",
+		Type:           PuzzleTypeSequence,
+		Steps:          []PuzzleStep{{Action: "pull red lever"}, {Action: "pull blue lever"}},
+		ResetOnMistake: true,
+		Reward:         "The door swings open.",
+	}
+	engine := NewEngine(scenario)
+
+	play(t, engine, "pull the red lever", "pull the red lever")
+	if engine.GetPuzzleProgress("door") != 0 {
+		t.Errorf("progress after a mistake = %d, want it reset", engine.GetPuzzleProgress("door"))
+	}
+	play(t, engine, "pull the red lever", "pull the blue lever")
+	if !engine.IsPuzzleSolved("door") {
+		t.Error("expected the levers in order to solve the door")
+	}
+}
+
+func TestSequencePuzzleWithRepeatedSteps(t *testing.T) {
+	scenario := testScenario()
+	scenario.Puzzles[0] = Puzzle{
+		ID:          "door",
+		Name:        "Door",
+		Description: "A dial and a lever.",
+		Type:        PuzzleTypeSequence,
+		Steps:       []PuzzleStep{{Action: "turn dial"}, {Action: "turn dial"}, {Action: "pull lever"}},
+		Reward:      "The door swings open.",
+	}
+	engine := NewEngine(scenario)
+
+	play(t, engine, "turn the dial", "pull the lever")
+	if engine.GetState().FailedAttempts["door"] != 1 {
+		t.Errorf("failed attempts = %d, want pulling the lever early to be a mistake", engine.GetState().FailedAttempts["door"])
+	}
+
+	engine = NewEngine(scenario)
+	play(t, engine, "turn the dial", "turn the dial", "pull the lever")
+	if !engine.IsPuzzleSolved("door") || engine.GetState().FailedAttempts["door"] != 0 {
+		t.Errorf("solved = %v with %d mistakes, want the repeated steps to solve it", engine.IsPuzzleSolved("door"), engine.GetState().FailedAttempts["door"])
+	}
+}
@@ -0,0 +1,184 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrRaceNotStarted = errors.New("the race hasn't started yet")
+
+// Race runs separate games of the same scenario against one clock and ranks
+// the players. Each racer's engine must only be used by one goroutine at a
+// time, but different racers can play concurrently.
+type Race struct {
+	mu       sync.Mutex
+	scenario *Scenario
+	started  time.Time
+	racers   []*Racer
+}
+
+type Racer struct {
+	Name   string
+	Engine *Engine
+
+	stats    GameStats // as of the racer's last command, guarded by Race.mu
+	finished time.Time
+}
+
+// Standing is a racer's place in the race.
+type Standing struct {
+	Rank int    `json:"rank"`
+	Name string `json:"name"`
+	GameStats
+	Finished bool `json:"finished"` // escaped or lost; elapsed is then their final time
+}
+
+func NewRace(scenario *Scenario) *Race {
+	return &Race{scenario: scenario}
+}
+
+func (r *Race) Scenario() *Scenario {
+	return r.scenario
+}
+
+// Join adds a racer with a fresh game. Their clock doesn't run until the race starts.
+func (r *Race) Join(name string) (*Racer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("a racer needs a name")
+	}
+	for _, racer := range r.racers {
+		if strings.EqualFold(racer.Name, name) {
+			return nil, fmt.Errorf("there's already a racer called %s", name)
+		}
+	}
+
+	engine := NewEngine(r.scenario)
+	engine.clockStarted = r.started
+	racer := &Racer{Name: name, Engine: engine, stats: engine.Stats()}
+	r.racers = append(r.racers, racer)
+	return racer, nil
+}
+
+// Start starts the clock for every racer.
+func (r *Race) Start() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.started.IsZero() {
+		return fmt.Errorf("the race has already started")
+	}
+	if len(r.racers) == 0 {
+		return fmt.Errorf("nobody has joined the race")
+	}
+	r.started = time.Now()
+	return nil
+}
+
+// StartedAt returns when the race started, or the zero time if it hasn't.
+func (r *Race) StartedAt() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.started
+}
+
+// Play runs a command in the racer's game and updates the standings.
+func (r *Race) Play(racer *Racer, command string) (*CommandResult, error) {
+	started := r.StartedAt()
+	if started.IsZero() {
+		return nil, ErrRaceNotStarted
+	}
+
+	// Everyone's clock runs from the start of the race
+	engine := racer.Engine
+	if engine.clockStarted.IsZero() && !engine.IsGameWon() && !engine.IsGameLost() {
+		engine.state.Elapsed = 0
+		engine.clockStarted = started
+	}
+
+	result, err := engine.ProcessCommand(command)
+
+	stats := engine.Stats()
+	r.mu.Lock()
+	racer.stats = stats
+	if (stats.Won || stats.Lost) && racer.finished.IsZero() {
+		racer.finished = started.Add(stats.Elapsed)
+	}
+	r.mu.Unlock()
+
+	return result, err
+}
+
+// Standings ranks the racers: those who escaped by time and then moves, then
+// those still playing by how far they've got, then those who lost.
+func (r *Race) Standings() []Standing {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	standings := make([]Standing, 0, len(r.racers))
+	for _, racer := range r.racers {
+		standing := Standing{Name: racer.Name, GameStats: racer.stats, Finished: !racer.finished.IsZero()}
+		if !r.started.IsZero() {
+			if standing.Finished {
+				standing.Elapsed = racer.finished.Sub(r.started)
+			} else {
+				standing.Elapsed = time.Since(r.started)
+			}
+		}
+		standings = append(standings, standing)
+	}
+
+	place := func(s Standing) int {
+		switch {
+		case s.Won:
+			return 0
+		case s.Lost:
+			return 2
+		}
+		return 1
+	}
+	sort.SliceStable(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		if place(a) != place(b) {
+			return place(a) < place(b)
+		}
+		if a.Won {
+			if a.Elapsed != b.Elapsed {
+				return a.Elapsed < b.Elapsed
+			}
+			return a.Moves < b.Moves
+		}
+		if a.PuzzlesSolved != b.PuzzlesSolved {
+			return a.PuzzlesSolved > b.PuzzlesSolved
+		}
+		if a.RoomsReached != b.RoomsReached {
+			return a.RoomsReached > b.RoomsReached
+		}
+		return a.Moves < b.Moves
+	})
+
+	for i := range standings {
+		standings[i].Rank = i + 1
+	}
+	return standings
+}
+
+// Finished reports whether every racer has escaped or lost.
+func (r *Race) Finished() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, racer := range r.racers {
+		if racer.finished.IsZero() {
+			return false
+		}
+	}
+	return len(r.racers) > 0
+}
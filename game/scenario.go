@@ -5,7 +5,11 @@ import (
 	"fmt"
 )
 
+// Scenario is the immutable definition of an escape room. Engines never modify it;
+// everything that changes during play lives in each session's WorldState, so one
+// Scenario can back any number of sessions at once.
 type Scenario struct {
+	SchemaVersion int              `json:"schema_version"`
 	Theme        string            `json:"theme"`
 	Setting      string            `json:"setting"`
 	BackStory    string            `json:"backstory"`
@@ -13,9 +17,15 @@ type Scenario struct {
 	Items        []Item            `json:"items"`
 	Puzzles      []Puzzle          `json:"puzzles"`
 	Actions      []Action          `json:"actions"`
+	NPCs         []NPC             `json:"npcs,omitempty"`
 	WinCondition string            `json:"win_condition"`
 	Hints        map[string]string `json:"hints"`
 	ProgressiveHints []ProgressiveHint `json:"progressive_hints"`
+	Timer        *Timer            `json:"timer,omitempty"`
+	Difficulty   string            `json:"difficulty,omitempty"` // "easy", "normal", "hard" or "expert"
+	FailConditions []FailCondition `json:"fail_conditions,omitempty"`
+	Scoring      *Scoring          `json:"scoring,omitempty"`
+	Achievements []Achievement     `json:"achievements,omitempty"`
 }
 
 type Room struct {
@@ -25,7 +35,8 @@ type Room struct {
 	Items       []string `json:"items"`
 	Puzzles     []string `json:"puzzles"`
 	Exits       []string `json:"exits"`
-	Locked      bool     `json:"locked"`
+	NPCs        []string `json:"npcs,omitempty"`
+	Locked      bool     `json:"locked"` // initial state; see WorldState.RoomLocked
 	UnlockKey   string   `json:"unlock_key,omitempty"`
 }
 
@@ -35,7 +46,7 @@ type Item struct {
 	Description string `json:"description"`
 	Usable      bool   `json:"usable"`
 	UseWith     string `json:"use_with,omitempty"`
-	Hidden      bool   `json:"hidden"`
+	Hidden      bool   `json:"hidden"` // initial state; see WorldState.ItemHidden
 	RevealedBy  string `json:"revealed_by,omitempty"`
 }
 
@@ -46,7 +57,12 @@ type Puzzle struct {
 	Solution    string   `json:"solution"`
 	RequiredItems []string `json:"required_items"`
 	Reward      string   `json:"reward"`
-	Solved      bool     `json:"solved"`
+	Type        string   `json:"type,omitempty"` // "answer" (default), "sequence", "combination", "keypad", "cipher", "riddle", "ordering"
+	Answers     []string `json:"answers,omitempty"` // other accepted answers for riddles
+	Cipher      *Cipher  `json:"cipher,omitempty"`
+	Steps       []PuzzleStep `json:"steps,omitempty"`
+	ResetOnMistake bool  `json:"reset_on_mistake,omitempty"`
+	MistakeMessage string `json:"mistake_message,omitempty"`
 }
 
 type Action struct {
@@ -59,13 +75,13 @@ type Action struct {
 }
 
 type ActionTrigger struct {
-	Type   string `json:"type"` // "examine", "use", "use_with", "take"
+	Type   string `json:"type"` // "examine", "use", "use_with", "take", "solve"
 	Target string `json:"target"` // item ID, room feature, etc.
 	With   string `json:"with,omitempty"` // for "use_with" actions
 }
 
 type ActionCondition struct {
-	Type  string `json:"type"` // "has_item", "in_room", "puzzle_solved", "action_performed"
+	Type  string `json:"type"` // "has_item", "in_room", "puzzle_solved", "action_performed", "topic_discussed"
 	Value string `json:"value"`
 }
 
@@ -79,7 +95,7 @@ type ProgressiveHint struct {
 	Context     string   `json:"context"` // "room_id" or "puzzle_id" 
 	Triggers    []HintTrigger `json:"triggers"`
 	HintText    string   `json:"hint_text"`
-	Priority    int      `json:"priority"` // Higher priority hints show first
+	Priority    int      `json:"priority"` // Higher priority hints show first, so give the gentlest nudge the highest number
 }
 
 type HintTrigger struct {
@@ -91,10 +107,13 @@ func (s *Scenario) ToJSON() ([]byte, error) {
 	return json.MarshalIndent(s, "", "  ")
 }
 
+// ScenarioFromJSON parses a scenario, migrating older formats to the current schema.
 func ScenarioFromJSON(data []byte) (*Scenario, error) {
 	var scenario Scenario
-	err := json.Unmarshal(data, &scenario)
-	return &scenario, err
+	if err := json.Unmarshal(data, &scenario); err != nil {
+		return &scenario, err
+	}
+	return &scenario, MigrateScenario(&scenario)
 }
 
 func (s *Scenario) GetRoom(id string) (*Room, error) {
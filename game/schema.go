@@ -0,0 +1,276 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Fields that must be present for a scenario to make sense. Everything else is
+// optional, matching what the loader accepts.
+var schemaRequired = map[string][]string{
+	"Scenario":        {"theme", "setting", "backstory", "rooms", "items", "puzzles", "win_condition"},
+	"Room":            {"id", "name", "description", "exits"},
+	"Item":            {"id", "name", "description"},
+	"Puzzle":          {"id", "name", "description"},
+	"Action":          {"id", "trigger"},
+	"ActionTrigger":   {"type", "target"},
+	"ActionCondition": {"type", "value"},
+	"ActionEffect":    {"type", "target"},
+	"ProgressiveHint": {"context", "triggers", "hint_text"},
+	"HintTrigger":     {"type", "threshold"},
+	"NPC":             {"id", "name"},
+	"NPCFact":         {"id", "fact"},
+	"DialogueTopic":   {"id", "keywords", "response"},
+	"Trade":           {"wants", "response"},
+	"Timer":           {"mode", "limit"},
+	"TimerWarning":    {"remaining", "message"},
+	"FailCondition":   {"id", "type"},
+	"Cipher":          {"method"},
+	"PuzzleStep":      {"action"},
+	"Achievement":     {"id", "name"},
+}
+
+var schemaEnums = map[string][]string{
+	"ActionTrigger.type":   {"examine", "use", "use_with", "take", "solve"},
+	"ActionCondition.type": {"has_item", "in_room", "puzzle_solved", "action_performed", "topic_discussed"},
+	"ActionEffect.type":    {"reveal_item", "hide_item", "unlock_room", "add_inventory", "remove_inventory"},
+	"HintTrigger.type":     {"failed_attempts", "time_spent", "commands_tried"},
+	"Puzzle.type":          {PuzzleTypeAnswer, PuzzleTypeSequence, PuzzleTypeCombination, PuzzleTypeKeypad, PuzzleTypeCipher, PuzzleTypeRiddle, PuzzleTypeOrdering},
+	"Timer.mode":           {TimerModeRealTime, TimerModeTurns},
+	"FailCondition.type":   {FailTimerExpired, FailFailedAttempts, FailActionTriggered},
+	"Cipher.method":        {CipherCaesar, CipherSubstitution},
+	"Scenario.difficulty":  {DifficultyEasy, DifficultyNormal, DifficultyHard, DifficultyExpert},
+}
+
+// ScenarioSchema returns a JSON Schema describing the scenario file format,
+// generated from the Scenario type so it can't drift from what the game loads.
+func ScenarioSchema() ([]byte, error) {
+	defs := make(map[string]interface{})
+	root := schemaFor(reflect.TypeOf(Scenario{}), defs)
+	delete(defs, "Scenario")
+
+	schema := map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "Go Escape AI scenario",
+		"description": "An escape room: rooms, items, puzzles, actions and hints.",
+		"$defs":       defs,
+	}
+	for key, value := range root {
+		schema[key] = value
+	}
+	// Lets authors point their editor at the schema from inside a scenario file
+	schema["properties"].(map[string]interface{})["$schema"] = map[string]interface{}{"type": "string"}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func schemaFor(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaFor(t.Elem(), defs)
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), defs)}
+	case reflect.Struct:
+		if t.Name() == "Scenario" {
+			return objectSchema(t, defs)
+		}
+		if _, exists := defs[t.Name()]; !exists {
+			defs[t.Name()] = nil // reserve the name in case the type refers to itself
+			defs[t.Name()] = objectSchema(t, defs)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+	}
+	return map[string]interface{}{}
+}
+
+func objectSchema(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "" || name == "-" || field.PkgPath != "" {
+			continue
+		}
+
+		property := schemaFor(field.Type, defs)
+		if values, exists := schemaEnums[t.Name()+"."+name]; exists {
+			property["enum"] = values
+		}
+
+		// Empty slices, maps and pointers without omitempty are written as null
+		switch field.Type.Kind() {
+		case reflect.Slice, reflect.Map, reflect.Ptr:
+			if len(tag) == 1 || tag[1] != "omitempty" {
+				property = nullable(property)
+			}
+		}
+		properties[name] = property
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if required := schemaRequired[t.Name()]; len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func nullable(property map[string]interface{}) map[string]interface{} {
+	if kind, ok := property["type"].(string); ok {
+		property["type"] = []string{kind, "null"}
+		return property
+	}
+	return map[string]interface{}{"anyOf": []interface{}{property, map[string]interface{}{"type": "null"}}}
+}
+
+// CheckSchema checks a scenario written as JSON against ScenarioSchema and
+// returns a description of each place it doesn't match. It only returns an
+// error if the data isn't JSON at all.
+func CheckSchema(data []byte) ([]string, error) {
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	raw, err := ScenarioSchema()
+	if err != nil {
+		return nil, err
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, err
+	}
+
+	defs, _ := schema["$defs"].(map[string]interface{})
+	return checkSchema(schema, defs, document, "scenario"), nil
+}
+
+// checkSchema checks a value against the parts of JSON Schema that
+// ScenarioSchema uses.
+func checkSchema(schema map[string]interface{}, defs map[string]interface{}, value interface{}, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		schema, _ = defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+	}
+
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		var problems []string
+		for _, option := range anyOf {
+			optionProblems := checkSchema(option.(map[string]interface{}), defs, value, path)
+			if len(optionProblems) == 0 {
+				return nil
+			}
+			problems = append(problems, optionProblems...)
+		}
+		return problems[:1]
+	}
+
+	var problems []string
+	report := func(format string, args ...interface{}) {
+		problems = append(problems, path+" "+fmt.Sprintf(format, args...))
+	}
+
+	if kinds := schemaTypes(schema["type"]); len(kinds) > 0 {
+		matched := false
+		for _, kind := range kinds {
+			matched = matched || schemaTypeMatches(kind, value)
+		}
+		if !matched {
+			report("should be %s", strings.Join(kinds, " or "))
+			return problems
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		allowed := false
+		var names []string
+		for _, option := range enum {
+			allowed = allowed || option == value
+			names = append(names, fmt.Sprint(option))
+		}
+		if !allowed {
+			report("is %q, not one of %s", fmt.Sprint(value), strings.Join(names, ", "))
+		}
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if _, exists := value[name.(string)]; !exists {
+					report("is missing %q", name)
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		additional, _ := schema["additionalProperties"].(map[string]interface{})
+		for name, field := range value {
+			if property, ok := properties[name].(map[string]interface{}); ok {
+				problems = append(problems, checkSchema(property, defs, field, path+"."+name)...)
+			} else if additional != nil {
+				problems = append(problems, checkSchema(additional, defs, field, path+"."+name)...)
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range value {
+				problems = append(problems, checkSchema(items, defs, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return problems
+}
+
+func schemaTypes(kind interface{}) []string {
+	switch kind := kind.(type) {
+	case string:
+		return []string{kind}
+	case []interface{}:
+		var kinds []string
+		for _, k := range kind {
+			kinds = append(kinds, fmt.Sprint(k))
+		}
+		return kinds
+	}
+	return nil
+}
+
+func schemaTypeMatches(kind string, value interface{}) bool {
+	switch kind {
+	case "null":
+		return value == nil
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == float64(int64(number))
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestScenarioSchemaIsUpToDate(t *testing.T) {
+	published, err := os.ReadFile("../scenario.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := ScenarioSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(published), bytes.TrimSpace(schema)) {
+		t.Error("scenario.schema.json is out of date; run go generate in cmd/go-escape-ai")
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	valid, err := GenerateScenario("Space Station", 3, DifficultyLevels[1]).ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems, err := CheckSchema(valid); err != nil || len(problems) > 0 {
+		t.Fatalf("generated scenario doesn't match the schema: %v %v", problems, err)
+	}
+
+	tests := []struct {
+		name    string
+		change  func(document map[string]interface{})
+		problem string
+	}{
+		{"missing field", func(d map[string]interface{}) { delete(d, "theme") }, `scenario is missing "theme"`},
+		{"wrong type", func(d map[string]interface{}) { d["rooms"] = "lots" }, "scenario.rooms should be array"},
+		{"bad enum", func(d map[string]interface{}) {
+			d["puzzles"].([]interface{})[0].(map[string]interface{})["type"] = "jigsaw"
+		}, `scenario.puzzles[0].type is "jigsaw"`},
+		{"fraction", func(d map[string]interface{}) { d["schema_version"] = 1.5 }, "scenario.schema_version should be integer"},
+		{"map value", func(d map[string]interface{}) {
+			d["hints"] = map[string]interface{}{"bridge": 4}
+		}, "scenario.hints.bridge should be string"},
+	}
+	for _, test := range tests {
+		var document map[string]interface{}
+		json.Unmarshal(valid, &document)
+		test.change(document)
+		data, _ := json.Marshal(document)
+
+		problems, err := CheckSchema(data)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if len(problems) != 1 || !strings.HasPrefix(problems[0], test.problem) {
+			t.Errorf("%s: problems = %q, want one starting %q", test.name, problems, test.problem)
+		}
+	}
+
+	if _, err := CheckSchema([]byte("{not json")); err == nil {
+		t.Error("expected an error for data that isn't JSON")
+	}
+}
@@ -0,0 +1,221 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Scoring configures how a game is scored. Point values left out take their
+// value from DefaultScoring; an explicit 0 turns that part of the score off.
+type Scoring struct {
+	PuzzlePoints           *int     `json:"puzzle_points,omitempty"` // for each puzzle solved
+	EscapePoints           *int     `json:"escape_points,omitempty"` // for escaping at all
+	TimeBonus              *int     `json:"time_bonus,omitempty"`    // for an instant escape, falling to nothing over TimeBonusSeconds
+	TimeBonusSeconds       *int     `json:"time_bonus_seconds,omitempty"`
+	HintPenalty            *int     `json:"hint_penalty,omitempty"`             // taken off for each hint asked for
+	ProgressiveHintPenalty *int     `json:"progressive_hint_penalty,omitempty"` // taken off for each progressive hint given by "hint"
+	Secrets                []string `json:"secrets,omitempty"`                  // optional items worth finding
+	SecretPoints           *int     `json:"secret_points,omitempty"`            // for each secret picked up
+}
+
+// PointValues are the points a game is actually scored with: the defaults,
+// with whatever the scenario's Scoring sets on top.
+type PointValues struct {
+	PuzzlePoints           int
+	EscapePoints           int
+	TimeBonus              int
+	TimeBonusSeconds       int
+	HintPenalty            int
+	ProgressiveHintPenalty int
+	SecretPoints           int
+}
+
+var DefaultScoring = PointValues{
+	PuzzlePoints:           100,
+	EscapePoints:           500,
+	TimeBonus:              300,
+	TimeBonusSeconds:       30 * 60,
+	HintPenalty:            25,
+	ProgressiveHintPenalty: 10,
+	SecretPoints:           50,
+}
+
+// Achievement is a scenario-defined goal, such as finding all the gold coins.
+// It's earned as soon as its conditions hold, or on escaping if OnEscape is set.
+type Achievement struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Conditions  []ActionCondition `json:"conditions,omitempty"`
+	OnEscape    bool              `json:"on_escape,omitempty"`
+	MaxHints    *int              `json:"max_hints,omitempty"` // hints asked for, checked on escaping
+	MaxMoves    *int              `json:"max_moves,omitempty"` // checked on escaping
+	Points      int               `json:"points,omitempty"`
+}
+
+// Score breaks down a player's points.
+type Score struct {
+	Puzzles      int `json:"puzzles"`
+	Secrets      int `json:"secrets"`
+	Achievements int `json:"achievements"`
+	Escape       int `json:"escape"`
+	TimeBonus    int `json:"time_bonus"`
+	Hints        int `json:"hints"` // zero or negative
+	Total        int `json:"total"`
+}
+
+func (s *Scenario) scoring() PointValues {
+	scoring := DefaultScoring
+	if s.Scoring == nil {
+		return scoring
+	}
+
+	for _, value := range s.Scoring.pointValues(&scoring) {
+		if value.custom != nil {
+			*value.scoring = *value.custom
+		}
+	}
+	return scoring
+}
+
+// pointValue is one of a scenario's point values and the value it sets.
+type pointValue struct {
+	name    string // as written in the scenario
+	custom  *int   // nil if the scenario leaves it out
+	scoring *int
+}
+
+// pointValues pairs each of the scenario's point values with the one it sets.
+func (s *Scoring) pointValues(scoring *PointValues) []pointValue {
+	return []pointValue{
+		{"puzzle_points", s.PuzzlePoints, &scoring.PuzzlePoints},
+		{"escape_points", s.EscapePoints, &scoring.EscapePoints},
+		{"time_bonus", s.TimeBonus, &scoring.TimeBonus},
+		{"time_bonus_seconds", s.TimeBonusSeconds, &scoring.TimeBonusSeconds},
+		{"hint_penalty", s.HintPenalty, &scoring.HintPenalty},
+		{"progressive_hint_penalty", s.ProgressiveHintPenalty, &scoring.ProgressiveHintPenalty},
+		{"secret_points", s.SecretPoints, &scoring.SecretPoints},
+	}
+}
+
+// secrets lists the scenario's optional items worth finding.
+func (s *Scenario) secrets() []string {
+	if s.Scoring == nil {
+		return nil
+	}
+	return s.Scoring.Secrets
+}
+
+func (e *Engine) Score() Score {
+	scoring := e.state.Scenario.scoring()
+
+	score := Score{
+		Puzzles: len(e.state.SolvedPuzzles) * scoring.PuzzlePoints,
+		Secrets: len(e.state.SecretsFound) * scoring.SecretPoints,
+		Hints:   -(e.state.HintsUsed*scoring.HintPenalty + len(e.state.HintsShown)*scoring.ProgressiveHintPenalty),
+	}
+	for _, achievement := range e.Achievements() {
+		score.Achievements += achievement.Points
+	}
+	if e.state.GameWon {
+		score.Escape = scoring.EscapePoints
+		if window := time.Duration(scoring.TimeBonusSeconds) * time.Second; window > 0 && e.Elapsed() < window {
+			score.TimeBonus = int(float64(scoring.TimeBonus) * float64(window-e.Elapsed()) / float64(window))
+		}
+	}
+
+	score.Total = score.Puzzles + score.Secrets + score.Achievements + score.Escape + score.TimeBonus + score.Hints
+	return score
+}
+
+func (s Score) String() string {
+	var parts []string
+	add := func(label string, points int) {
+		if points != 0 {
+			parts = append(parts, fmt.Sprintf("%s %+d", label, points))
+		}
+	}
+	add("puzzles", s.Puzzles)
+	add("secrets", s.Secrets)
+	add("achievements", s.Achievements)
+	add("escape", s.Escape)
+	add("time bonus", s.TimeBonus)
+	add("hints", s.Hints)
+
+	if len(parts) == 0 {
+		return fmt.Sprintf("%d points", s.Total)
+	}
+	return fmt.Sprintf("%d points (%s)", s.Total, strings.Join(parts, ", "))
+}
+
+// Achievements returns the achievements earned so far.
+func (e *Engine) Achievements() []Achievement {
+	var earned []Achievement
+	for _, achievement := range e.state.Scenario.Achievements {
+		if e.hasAchievement(achievement.ID) {
+			earned = append(earned, achievement)
+		}
+	}
+	return earned
+}
+
+func (e *Engine) hasAchievement(id string) bool {
+	for _, earned := range e.state.Achievements {
+		if earned == id {
+			return true
+		}
+	}
+	return false
+}
+
+// checkScoring notes newly found secrets and earned achievements. Both are
+// kept even if the moves that earned them are undone.
+func (e *Engine) checkScoring() {
+	if e.state.GameLost {
+		return
+	}
+
+	for _, itemID := range e.state.Scenario.secrets() {
+		if !e.HasItem(itemID) || e.hasFoundSecret(itemID) {
+			continue
+		}
+		e.state.SecretsFound = append(e.state.SecretsFound, itemID)
+		name := itemID
+		if item, err := e.state.Scenario.GetItem(itemID); err == nil {
+			name = item.Name
+		}
+		e.emit(Event{Type: EventSecretFound, Target: itemID, Message: fmt.Sprintf("You found a secret: the %s!", name)})
+	}
+
+	for _, achievement := range e.state.Scenario.Achievements {
+		if e.hasAchievement(achievement.ID) || !e.earnedAchievement(achievement) {
+			continue
+		}
+		e.state.Achievements = append(e.state.Achievements, achievement.ID)
+		e.emit(Event{Type: EventAchievementEarned, Target: achievement.ID, Message: fmt.Sprintf("Achievement unlocked: %s", achievement.Name)})
+	}
+}
+
+func (e *Engine) hasFoundSecret(itemID string) bool {
+	for _, found := range e.state.SecretsFound {
+		if found == itemID {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Engine) earnedAchievement(achievement Achievement) bool {
+	escaping := achievement.OnEscape || achievement.MaxHints != nil || achievement.MaxMoves != nil
+	if escaping && !e.state.GameWon {
+		return false
+	}
+	if achievement.MaxHints != nil && e.state.HintsUsed > *achievement.MaxHints {
+		return false
+	}
+	if achievement.MaxMoves != nil && e.state.Moves > *achievement.MaxMoves {
+		return false
+	}
+	return e.checkActionConditions(achievement.Conditions)
+}
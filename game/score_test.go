@@ -0,0 +1,114 @@
+package game
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScoreWithoutHints(t *testing.T) {
+	engine := NewEngine(testScenario())
+	play(t, engine, "look", "solve shut", "solve open", "go hall", "solve swordfish")
+
+	if !engine.IsGameWon() {
+		t.Fatal("game not won")
+	}
+	if len(engine.GetState().HintsUnlocked) == 0 {
+		t.Fatal("expected progressive hints to unlock by themselves")
+	}
+	score := engine.Score()
+	if score.Hints != 0 {
+		t.Errorf("hint penalty without asking for a hint = %d, want 0", score.Hints)
+	}
+	if want := 2*DefaultScoring.PuzzlePoints + DefaultScoring.EscapePoints + score.TimeBonus; score.Total != want {
+		t.Errorf("total = %d, want %d", score.Total, want)
+	}
+}
+
+func TestScoreChargesEachHintTierOnce(t *testing.T) {
+	engine := NewEngine(testScenario())
+	play(t, engine, "hint", "hint", "hint")
+
+	stats := engine.Stats()
+	if stats.HintsUsed != 3 || stats.HintsShown != 2 {
+		t.Fatalf("hints used %d, shown %d; want 3 and 2", stats.HintsUsed, stats.HintsShown)
+	}
+	want := -(3*DefaultScoring.HintPenalty + 2*DefaultScoring.ProgressiveHintPenalty)
+	if score := engine.Score(); score.Hints != want {
+		t.Errorf("hint penalty = %d, want %d", score.Hints, want)
+	}
+}
+
+func TestScoreCustomValues(t *testing.T) {
+	scenario := testScenario()
+	puzzlePoints, secretPoints := 7, 3
+	scenario.Scoring = &Scoring{PuzzlePoints: &puzzlePoints, Secrets: []string{"spoon"}, SecretPoints: &secretPoints}
+	engine := NewEngine(scenario)
+	play(t, engine, "take spoon", "solve open")
+
+	score := engine.Score()
+	if score.Puzzles != 7 || score.Secrets != 3 || score.Escape != 0 {
+		t.Errorf("score = %+v, want 7 for puzzles, 3 for secrets and no escape", score)
+	}
+}
+
+func TestScoreFrozenAfterGameEnds(t *testing.T) {
+	won := NewEngine(testScenario())
+	play(t, won, "solve open", "go hall", "solve swordfish")
+
+	lost := NewEngine(testScenario())
+	lost.state.Scenario.FailConditions = []FailCondition{{ID: "guessed", Type: FailFailedAttempts, Threshold: 1, Message: "The door locks for good."}}
+	play(t, lost, "solve shut")
+
+	for name, engine := range map[string]*Engine{"won": won, "lost": lost} {
+		if !engine.IsGameWon() && !engine.IsGameLost() {
+			t.Fatalf("%s: game still going", name)
+		}
+		elapsed, score := engine.Stats().Elapsed, engine.Score()
+		time.Sleep(10 * time.Millisecond)
+		engine.Resume()
+		if engine.Stats().Elapsed != elapsed || engine.Score() != score {
+			t.Errorf("%s: time or score changed after the game ended", name)
+		}
+	}
+}
+
+func TestScoreExplicitZero(t *testing.T) {
+	scenario := testScenario()
+	none := 0
+	scenario.Scoring = &Scoring{HintPenalty: &none, ProgressiveHintPenalty: &none, TimeBonus: &none}
+	engine := NewEngine(scenario)
+	play(t, engine, "hint", "solve open", "go hall", "solve swordfish")
+
+	score := engine.Score()
+	if score.Hints != 0 || score.TimeBonus != 0 {
+		t.Errorf("score = %+v, want no hint penalty and no time bonus", score)
+	}
+	if score.Escape != DefaultScoring.EscapePoints {
+		t.Errorf("escape = %d, want the default %d for a value left out", score.Escape, DefaultScoring.EscapePoints)
+	}
+
+	// An explicit zero survives saving and loading
+	data, err := scenario.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ScenarioFromJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Scoring.HintPenalty == nil || *loaded.Scoring.HintPenalty != 0 || loaded.Scoring.EscapePoints != nil {
+		t.Errorf("loaded scoring = %+v", loaded.Scoring)
+	}
+}
+
+func TestValidateRejectsNegativeScoring(t *testing.T) {
+	scenario := testScenario()
+	negative := -5
+	scenario.Scoring = &Scoring{PuzzlePoints: &negative}
+
+	problems := scenario.Validate()
+	if len(problems) != 1 || !strings.Contains(problems[0], "puzzle_points") {
+		t.Errorf("problems = %v, want the negative puzzle_points reported", problems)
+	}
+}
@@ -0,0 +1,218 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultSolverLimit is how many distinct world states Solve explores before giving up.
+const DefaultSolverLimit = 20000
+
+type SolutionStep struct {
+	Command string  `json:"command"`
+	Events  []Event `json:"events"`
+}
+
+// Solve searches for the shortest sequence of commands that wins the scenario
+// by playing it breadth-first, trying every command that could make progress
+// in each state. It returns an error if no win is found within limit states.
+func Solve(s *Scenario, limit int) ([]SolutionStep, error) {
+	if len(s.Rooms) == 0 {
+		return nil, fmt.Errorf("scenario has no rooms")
+	}
+	if limit <= 0 {
+		limit = DefaultSolverLimit
+	}
+
+	type node struct {
+		state *GameState
+		path  []SolutionStep
+	}
+
+	relevant := referencedIDs(s)
+	start := newGameState(s)
+	seen := map[string]bool{worldKey(start.WorldState): true}
+	queue := []node{{state: start}}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, command := range (&Engine{state: current.state}).candidateCommands(relevant) {
+			engine := &Engine{state: current.state.clone()}
+			result, err := engine.ProcessCommand(command)
+			if err != nil || engine.IsGameLost() {
+				continue
+			}
+
+			path := append(append([]SolutionStep{}, current.path...), SolutionStep{Command: command, Events: result.Events})
+			if engine.IsGameWon() {
+				return path, nil
+			}
+
+			key := worldKey(engine.state.WorldState)
+			if seen[key] {
+				continue
+			}
+			if len(seen) >= limit {
+				return nil, fmt.Errorf("no solution found within %d states", limit)
+			}
+			seen[key] = true
+			queue = append(queue, node{state: engine.state, path: path})
+		}
+	}
+
+	return nil, fmt.Errorf("scenario cannot be won: explored all %d reachable states", len(seen))
+}
+
+// worldKey identifies a world state regardless of the order things happened in.
+func worldKey(world WorldState) string {
+	world = world.clone()
+	world.DiscoveredItems = nil
+	for _, list := range [][]string{world.Inventory, world.SolvedPuzzles, world.PerformedActions, world.DiscussedTopics} {
+		sort.Strings(list)
+	}
+	data, _ := json.Marshal(world)
+	return string(data)
+}
+
+// referencedIDs collects every ID the scenario's logic depends on. Taking items
+// and performing actions that nothing depends on can't help win, so the solver
+// skips them rather than exploring every order of doing them.
+func referencedIDs(s *Scenario) map[string]bool {
+	ids := make(map[string]bool)
+	addConditions := func(conditions []ActionCondition) {
+		for _, condition := range conditions {
+			ids[condition.Value] = true
+		}
+	}
+
+	for _, room := range s.Rooms {
+		ids[room.UnlockKey] = true
+	}
+	for _, puzzle := range s.Puzzles {
+		for _, item := range puzzle.RequiredItems {
+			ids[item] = true
+		}
+	}
+	for _, action := range s.Actions {
+		ids[action.Trigger.With] = true
+		if action.Trigger.Type == "use" || action.Trigger.Type == "use_with" {
+			ids[action.Trigger.Target] = true
+		}
+		addConditions(action.Conditions)
+	}
+	for _, npc := range s.NPCs {
+		for _, topic := range npc.Topics {
+			addConditions(topic.Conditions)
+		}
+		for _, trade := range npc.Trades {
+			ids[trade.Wants] = true
+			addConditions(trade.Conditions)
+		}
+	}
+	for _, fail := range s.FailConditions {
+		ids[fail.Target] = true
+	}
+	return ids
+}
+
+// candidateCommands lists the commands that could change the world from the
+// current state: triggering actions, taking items, moving, working puzzles and
+// talking or trading with NPCs.
+func (e *Engine) candidateCommands(relevant map[string]bool) []string {
+	room, err := e.GetCurrentRoom()
+	if err != nil {
+		return nil
+	}
+
+	var commands []string
+	add := func(format string, args ...interface{}) {
+		command := strings.ToLower(fmt.Sprintf(format, args...))
+		for _, existing := range commands {
+			if existing == command {
+				return
+			}
+		}
+		commands = append(commands, command)
+	}
+
+	for _, action := range e.state.Scenario.Actions {
+		if action.OneTimeOnly && e.hasPerformedAction(action.ID) {
+			continue
+		}
+		if !e.checkActionConditions(action.Conditions) {
+			continue
+		}
+		if len(action.Effects) == 0 && !relevant[action.ID] {
+			continue
+		}
+
+		// Triggers match on the raw target text, so use it as-is
+		target := action.Trigger.Target
+		switch action.Trigger.Type {
+		case "examine":
+			add("look %s", target)
+		case "take":
+			add("take %s", target)
+		case "use":
+			add("use %s", target)
+		case "use_with":
+			add("use %s with %s", target, action.Trigger.With)
+		}
+	}
+
+	for _, itemID := range room.Items {
+		item, err := e.state.Scenario.GetItem(itemID)
+		if err != nil || e.state.IsItemHidden(itemID) || e.HasItem(itemID) || !relevant[itemID] {
+			continue
+		}
+		add("take %s", item.Name)
+	}
+
+	for _, exitID := range room.Exits {
+		add("go %s", exitID)
+	}
+
+	for _, puzzleID := range room.Puzzles {
+		puzzle, err := e.state.Scenario.GetPuzzle(puzzleID)
+		if err != nil || e.IsPuzzleSolved(puzzleID) || !e.hasRequiredItems(puzzle) {
+			continue
+		}
+
+		switch {
+		case puzzle.Type == PuzzleTypeSequence:
+			if progress := e.state.PuzzleProgress[puzzleID]; progress < len(puzzle.Steps) {
+				add("%s", puzzle.Steps[progress].Action)
+			}
+		case puzzle.IsCodeLock():
+			add("enter %s on %s", puzzle.Solution, puzzle.Name)
+		case puzzle.Type == PuzzleTypeOrdering:
+			add("arrange %s", puzzle.Solution)
+		default:
+			add("solve %s", puzzle.Solution)
+		}
+	}
+
+	for _, npcID := range room.NPCs {
+		npc, err := e.state.Scenario.GetNPC(npcID)
+		if err != nil {
+			continue
+		}
+		for _, topic := range e.availableTopics(npc) {
+			if len(topic.Keywords) > 0 {
+				add("ask %s about %s", npc.Name, topic.Keywords[0])
+			}
+		}
+		for _, trade := range npc.Trades {
+			item, err := e.state.Scenario.GetItem(trade.Wants)
+			if err == nil && e.HasItem(trade.Wants) && e.checkActionConditions(trade.Conditions) {
+				add("give %s to %s", item.Name, npc.Name)
+			}
+		}
+	}
+
+	return commands
+}
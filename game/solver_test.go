@@ -0,0 +1,47 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSolveFindsShortestWin(t *testing.T) {
+	steps, err := Solve(testScenario(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var commands []string
+	for _, step := range steps {
+		commands = append(commands, step.Command)
+	}
+	if len(commands) != 3 {
+		t.Fatalf("solution = %q, want three commands", commands)
+	}
+
+	// Playing the solution back wins
+	engine := NewEngine(testScenario())
+	play(t, engine, commands...)
+	if !engine.IsGameWon() {
+		t.Errorf("playing %q didn't win", commands)
+	}
+}
+
+func TestSolveReportsUnwinnable(t *testing.T) {
+	scenario := testScenario()
+	scenario.Rooms[1].Locked = true
+	scenario.Rooms[1].UnlockKey = "gate_key" // nothing gives it
+
+	if _, err := Solve(scenario, 0); err == nil || !strings.Contains(err.Error(), "cannot be won") {
+		t.Errorf("err = %v, want the scenario reported as unwinnable", err)
+	}
+}
+
+func TestSolveGeneratedScenarios(t *testing.T) {
+	for _, level := range DifficultyLevels {
+		scenario := GenerateScenario("Haunted Lighthouse", 3, level)
+		if _, err := Solve(scenario, 0); err != nil {
+			t.Errorf("%s: %v", level.Name, err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package game
+
+import (
+	"fmt"
+	"time"
+)
+
+// GameStats summarizes a player's progress.
+type GameStats struct {
+	Moves         int           `json:"moves"`
+	CommandsTried int           `json:"commands_tried"`
+	Undos         int           `json:"undos"`
+	Elapsed       time.Duration `json:"elapsed"`
+	PuzzlesSolved int           `json:"puzzles_solved"`
+	PuzzlesTotal  int           `json:"puzzles_total"`
+	RoomsReached  int           `json:"rooms_reached"`
+	RoomsTotal    int           `json:"rooms_total"`
+	HintsUnlocked int           `json:"hints_unlocked"`
+	HintsUsed     int           `json:"hints_used"`
+	HintsShown    int           `json:"hints_shown"` // distinct progressive hints given on request
+	Score         int           `json:"score"`
+	Achievements  []string      `json:"achievements"` // names of those earned
+	Won           bool          `json:"won"`
+	Lost          bool          `json:"lost"`
+}
+
+func (e *Engine) Stats() GameStats {
+	stats := GameStats{
+		Moves:         e.state.Moves,
+		CommandsTried: e.state.CommandAttempts,
+		Undos:         e.state.Undos,
+		Elapsed:       e.Elapsed(),
+		PuzzlesSolved: len(e.state.SolvedPuzzles),
+		PuzzlesTotal:  len(e.state.Scenario.Puzzles),
+		RoomsReached:  len(e.state.RoomsVisited),
+		RoomsTotal:    len(e.state.Scenario.Rooms),
+		HintsUnlocked: len(e.state.HintsUnlocked),
+		HintsUsed:     e.state.HintsUsed,
+		HintsShown:    len(e.state.HintsShown),
+		Score:         e.Score().Total,
+		Achievements:  []string{},
+		Won:           e.state.GameWon,
+		Lost:          e.state.GameLost,
+	}
+	for _, achievement := range e.Achievements() {
+		stats.Achievements = append(stats.Achievements, achievement.Name)
+	}
+	return stats
+}
+
+func (s GameStats) String() string {
+	return fmt.Sprintf("Moves: %d, Time: %v, Puzzles solved: %d/%d, Hints used: %d, Score: %d",
+		s.Moves,
+		s.Elapsed.Round(time.Second),
+		s.PuzzlesSolved,
+		s.PuzzlesTotal,
+		s.HintsUsed,
+		s.Score)
+}
+
+func (s *GameState) visitRoom(roomID string) {
+	for _, visited := range s.RoomsVisited {
+		if visited == roomID {
+			return
+		}
+	}
+	s.RoomsVisited = append(s.RoomsVisited, roomID)
+}
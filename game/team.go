@@ -0,0 +1,528 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Team is a cooperative game: several players share one world, so revealed
+// items, unlocked rooms and solved puzzles are the same for everyone, while
+// each player has their own location and inventory. It is safe for concurrent
+// use; commands are processed one at a time.
+type Team struct {
+	mu        sync.Mutex
+	engine    *Engine
+	startRoom string
+	players   []*teamPlayer
+	nextID    int
+	voice     DialogueVoice
+	done      chan struct{} // closed once the game is won or lost
+	finalists []Player      // who was playing when it ended
+}
+
+// noticeBuffer is how many notices a player can fall behind by before further
+// ones are dropped.
+const noticeBuffer = 64
+
+type teamPlayer struct {
+	Player
+	notices chan TeamEvent // nil if the player didn't ask to be told anything
+}
+
+// tell queues a notice for the player without waiting for it to be delivered,
+// so a player whose connection has stalled can't hold up the team.
+func (p *teamPlayer) tell(from string, event Event) {
+	if p.notices == nil {
+		return
+	}
+	select {
+	case p.notices <- TeamEvent{Player: from, Event: event}:
+	default:
+	}
+}
+
+type Player struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	CurrentRoom string   `json:"current_room"`
+	Inventory   []string `json:"inventory"`
+}
+
+// TeamEvent tells a player about something another player did.
+type TeamEvent struct {
+	Player string `json:"player"` // name of the player who did it
+	Event
+}
+
+// sharedEvents change the world for the whole team, so everyone hears about them.
+var sharedEvents = map[string]bool{
+	EventActionTriggered:   true,
+	EventItemRevealed:      true,
+	EventRoomUnlocked:      true,
+	EventPuzzleSolved:      true,
+	EventTimerWarning:      true,
+	EventSecretFound:       true,
+	EventAchievementEarned: true,
+	EventGameWon:           true,
+	EventGameLost:          true,
+}
+
+func NewTeam(scenario *Scenario) *Team {
+	engine := NewEngine(scenario)
+	return &Team{engine: engine, startRoom: engine.state.CurrentRoom, done: make(chan struct{})}
+}
+
+// SetDialogueVoice voices NPCs with personas. The voice is asked for replies
+// while the team is unlocked, so a slow reply only holds up the player asking.
+func (t *Team) SetDialogueVoice(voice DialogueVoice) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.voice = voice
+}
+
+// Done is closed once the team has won or lost, so players waiting for input
+// can stop as soon as a teammate ends the game.
+func (t *Team) Done() <-chan struct{} {
+	return t.done
+}
+
+// Join adds a player in the starting room and returns their ID. Notify is
+// called with everything the rest of the team does, in order, from a goroutine
+// of the player's own and never while the team is locked, so a slow notify only
+// holds up that player's notices.
+func (t *Team) Join(name string, notify func(TeamEvent)) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("a player needs a name")
+	}
+	if t.findPlayer(name) != nil {
+		return "", fmt.Errorf("there's already a player called %s", name)
+	}
+
+	t.nextID++
+	player := &teamPlayer{
+		Player: Player{
+			ID:          fmt.Sprintf("p%d", t.nextID),
+			Name:        name,
+			CurrentRoom: t.startRoom,
+			Inventory:   []string{},
+		},
+	}
+	if notify != nil {
+		player.notices = make(chan TeamEvent, noticeBuffer)
+		go func(notices <-chan TeamEvent) {
+			for event := range notices {
+				notify(event)
+			}
+		}(player.notices)
+	}
+	t.players = append(t.players, player)
+	t.broadcast(player, Event{Type: EventPlayerJoined, Message: fmt.Sprintf("%s joined the team.", name)})
+	return player.ID, nil
+}
+
+// Leave removes a player. Items they picked up in a room go back to that room;
+// anything else they were carrying, such as items given to them by actions or
+// characters, is handed to a teammate, preferably one in the same room, so the
+// team can still escape.
+func (t *Team) Leave(playerID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, player := range t.players {
+		if player.ID != playerID {
+			continue
+		}
+		t.players = append(t.players[:i], t.players[i+1:]...)
+		t.broadcast(player, Event{Type: EventPlayerLeft, Message: fmt.Sprintf("%s left the team.", player.Name)})
+		t.handOver(player)
+		if player.notices != nil {
+			close(player.notices)
+		}
+		return
+	}
+}
+
+// handOver gives a leaving player's items that don't belong to a room to a teammate.
+func (t *Team) handOver(leaving *teamPlayer) {
+	if len(t.players) == 0 {
+		return
+	}
+	recipient := t.players[0]
+	for _, player := range t.players {
+		if player.CurrentRoom == leaving.CurrentRoom {
+			recipient = player
+			break
+		}
+	}
+
+	for _, itemID := range leaving.Inventory {
+		if t.placedInRoom(itemID) {
+			continue
+		}
+		recipient.Inventory = append(recipient.Inventory, itemID)
+		name := itemID
+		if item, err := t.engine.state.Scenario.GetItem(itemID); err == nil {
+			name = item.Name
+		}
+		recipient.tell(leaving.Name, Event{
+			Type:    EventItemGiven,
+			Target:  itemID,
+			Message: fmt.Sprintf("%s hands you the %s on the way out.", leaving.Name, name),
+		})
+	}
+}
+
+func (t *Team) placedInRoom(itemID string) bool {
+	for _, room := range t.engine.state.Scenario.Rooms {
+		for _, placed := range room.Items {
+			if placed == itemID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Players returns a copy of every player's details.
+func (t *Team) Players() []Player {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.copyPlayers()
+}
+
+// Finalists returns the players who were on the team when the game was won or
+// lost, even if they've left since, or nil while the game is still going.
+func (t *Team) Finalists() []Player {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.finalists
+}
+
+func (t *Team) copyPlayers() []Player {
+	var players []Player
+	for _, player := range t.players {
+		copied := player.Player
+		copied.Inventory = append([]string{}, player.Inventory...)
+		players = append(players, copied)
+	}
+	return players
+}
+
+func (t *Team) Scenario() *Scenario {
+	return t.engine.state.Scenario
+}
+
+// ProcessCommand runs a command for one player. Besides the usual commands,
+// players can "say" something to the team, "give" items to each other, and
+// ask "who" is where.
+func (t *Team) ProcessCommand(playerID, command string) (*CommandResult, error) {
+	replies := t.voiceReplies(playerID, command)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	player := t.player(playerID)
+	if player == nil {
+		return nil, fmt.Errorf("no player %q", playerID)
+	}
+
+	reply := func(message string) *CommandResult {
+		return &CommandResult{Command: command, Events: []Event{{Type: EventMessage, Message: message}}}
+	}
+
+	words := strings.Fields(strings.ToLower(command))
+	if len(words) > 0 {
+		switch words[0] {
+		case "say":
+			message := strings.TrimSpace(strings.TrimSpace(command)[len("say"):])
+			if message == "" {
+				return reply("Say what?"), nil
+			}
+			t.broadcast(player, Event{Type: EventSaid, Message: message})
+			return reply(fmt.Sprintf("You say: %s", message)), nil
+		case "who", "team":
+			return reply(t.describePlayers(player)), nil
+		case "undo":
+			return reply("You can't take back moves in a team game."), nil
+		case "give":
+			if result, handled := t.givePlayer(player, command, words[1:]); handled {
+				return result, nil
+			}
+		}
+	}
+
+	t.enter(t.engine, player)
+	t.engine.SetDialogueVoice(replies)
+	result, err := t.engine.ProcessCommand(command)
+	t.engine.SetDialogueVoice(nil)
+	t.engine.elsewhere = nil
+
+	from := player.CurrentRoom
+	player.CurrentRoom = t.engine.state.CurrentRoom
+	player.Inventory = t.engine.state.Inventory
+
+	t.announce(player, from, result)
+	if t.engine.IsGameWon() || t.engine.IsGameLost() {
+		select {
+		case <-t.done:
+		default:
+			t.finalists = t.copyPlayers()
+			close(t.done)
+		}
+	}
+	return result, err
+}
+
+// enter puts the player in the engine, with whatever the others are carrying
+// out of the rooms.
+func (t *Team) enter(engine *Engine, player *teamPlayer) {
+	engine.state.CurrentRoom = player.CurrentRoom
+	engine.state.Inventory = player.Inventory
+	engine.elsewhere = make(map[string]bool)
+	for _, other := range t.players {
+		if other == player {
+			continue
+		}
+		for _, itemID := range other.Inventory {
+			engine.elsewhere[itemID] = true
+		}
+	}
+}
+
+// voiceReplies asks the team's voice for any NPC replies the command needs
+// without holding the team lock. The command is tried on a copy of the game
+// to find out what it will ask; if the game moves on meanwhile and the
+// command asks something else, that NPC falls back to their authored line.
+func (t *Team) voiceReplies(playerID, command string) DialogueVoice {
+	t.mu.Lock()
+	voice := t.voice
+	player := t.player(playerID)
+	if voice == nil || player == nil {
+		t.mu.Unlock()
+		return nil
+	}
+	trial := &Engine{state: t.engine.state.clone()}
+	t.enter(trial, player)
+	asked := &recordedVoice{}
+	trial.SetDialogueVoice(asked)
+	trial.ProcessCommand(command)
+	t.mu.Unlock()
+
+	replies := make(cachedVoice)
+	for _, req := range asked.requests {
+		if reply, err := voice.VoiceNPC(req); err == nil {
+			replies[dialogueKey(req)] = reply
+		}
+	}
+	return replies
+}
+
+// recordedVoice notes what it's asked and leaves the NPC to their authored line.
+type recordedVoice struct {
+	requests []DialogueRequest
+}
+
+func (v *recordedVoice) VoiceNPC(req DialogueRequest) (string, error) {
+	v.requests = append(v.requests, req)
+	return "", fmt.Errorf("not voiced yet")
+}
+
+// cachedVoice answers with replies fetched in advance.
+type cachedVoice map[string]string
+
+func (v cachedVoice) VoiceNPC(req DialogueRequest) (string, error) {
+	reply, ok := v[dialogueKey(req)]
+	if !ok {
+		return "", fmt.Errorf("no reply for %s", req.NPC.ID)
+	}
+	return reply, nil
+}
+
+func dialogueKey(req DialogueRequest) string {
+	return strings.Join(append([]string{req.NPC.ID, req.Question, req.AuthoredLine}, req.Facts...), "\x00")
+}
+
+// announce tells players in the same room what the player did, and everyone
+// about changes to the shared world.
+func (t *Team) announce(player *teamPlayer, from string, result *CommandResult) {
+	for _, other := range t.players {
+		if other == player {
+			continue
+		}
+		if other.CurrentRoom == from || other.CurrentRoom == player.CurrentRoom {
+			other.tell(player.Name, Event{
+				Type:    EventPlayerAction,
+				Target:  player.CurrentRoom,
+				Message: fmt.Sprintf("%s: %s", player.Name, result.Command),
+			})
+		}
+		for _, event := range result.Events {
+			if sharedEvents[event.Type] {
+				other.tell(player.Name, event)
+			}
+		}
+	}
+}
+
+func (t *Team) broadcast(from *teamPlayer, event Event) {
+	for _, player := range t.players {
+		if player != from {
+			player.tell(from.Name, event)
+		}
+	}
+}
+
+// givePlayer hands an item to another player in the same room. It reports
+// false if the recipient isn't a player, so NPC trades still work.
+func (t *Team) givePlayer(player *teamPlayer, command string, args []string) (*CommandResult, bool) {
+	parts := strings.SplitN(strings.Join(args, " "), " to ", 2)
+	if len(parts) != 2 {
+		return nil, false
+	}
+	recipient := t.findPlayer(strings.TrimSpace(parts[1]))
+	if recipient == nil {
+		return nil, false
+	}
+
+	result := &CommandResult{Command: command}
+	say := func(message string) {
+		result.Events = append(result.Events, Event{Type: EventMessage, Message: message})
+	}
+
+	switch {
+	case recipient == player:
+		say("You already have it.")
+		return result, true
+	case recipient.CurrentRoom != player.CurrentRoom:
+		say(fmt.Sprintf("%s isn't here.", recipient.Name))
+		return result, true
+	}
+
+	itemName := strings.TrimSpace(parts[0])
+	for i, itemID := range player.Inventory {
+		item, err := t.engine.state.Scenario.GetItem(itemID)
+		if err != nil || !strings.Contains(strings.ToLower(item.Name), itemName) {
+			continue
+		}
+
+		player.Inventory = append(player.Inventory[:i:i], player.Inventory[i+1:]...)
+		recipient.Inventory = append(recipient.Inventory, itemID)
+		result.Events = append(result.Events, Event{Type: EventItemRemoved, Target: itemID, Message: fmt.Sprintf("You give the %s to %s.", item.Name, recipient.Name)})
+		recipient.tell(player.Name, Event{
+			Type:    EventItemGiven,
+			Target:  itemID,
+			Message: fmt.Sprintf("%s gives you the %s.", player.Name, item.Name),
+		})
+		return result, true
+	}
+
+	say(fmt.Sprintf("You don't have %s.", itemName))
+	return result, true
+}
+
+func (t *Team) describePlayers(you *teamPlayer) string {
+	var lines []string
+	for _, player := range t.players {
+		where := player.CurrentRoom
+		if room, err := t.engine.state.Scenario.GetRoom(where); err == nil {
+			where = room.Name
+		}
+		name := player.Name
+		if player == you {
+			name += " (you)"
+		}
+		lines = append(lines, fmt.Sprintf("%s is in %s, carrying %d item(s).", name, where, len(player.Inventory)))
+	}
+	return strings.Join(lines, " ")
+}
+
+func (t *Team) player(id string) *teamPlayer {
+	for _, player := range t.players {
+		if player.ID == id {
+			return player
+		}
+	}
+	return nil
+}
+
+func (t *Team) findPlayer(name string) *teamPlayer {
+	for _, player := range t.players {
+		if strings.EqualFold(player.Name, name) {
+			return player
+		}
+	}
+	return nil
+}
+
+// Snapshot returns the game state as one player sees it.
+func (t *Team) Snapshot(playerID string) *GameState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.engine.state.clone()
+	if player := t.player(playerID); player != nil {
+		state.CurrentRoom = player.CurrentRoom
+		state.Inventory = append([]string{}, player.Inventory...)
+	}
+	return state
+}
+
+func (t *Team) IsGameWon() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.engine.IsGameWon()
+}
+
+func (t *Team) IsGameLost() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.engine.IsGameLost()
+}
+
+func (t *Team) GetEndingMessage() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.engine.GetEndingMessage()
+}
+
+func (t *Team) GetGameStats() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.engine.GetGameStats()
+}
+
+func (t *Team) Stats() GameStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.engine.Stats()
+}
+
+func (t *Team) Score() Score {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.engine.Score()
+}
+
+func (t *Team) Achievements() []Achievement {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.engine.Achievements()
+}
+
+func (t *Team) Elapsed() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.engine.Elapsed()
+}
+
+func (t *Team) TimerStatus() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.engine.TimerStatus()
+}
@@ -0,0 +1,200 @@
+package game
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTeamNoticesDontWaitForStalledPlayers(t *testing.T) {
+	team := NewTeam(testScenario())
+	stalled := make(chan struct{})
+	defer close(stalled)
+
+	ann, err := team.Join("Ann", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := team.Join("Ben", func(TeamEvent) { <-stalled }); err != nil {
+		t.Fatal(err)
+	}
+	notices := make(chan TeamEvent, 2*noticeBuffer)
+	if _, err := team.Join("Cat", func(event TeamEvent) { notices <- event }); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 2*noticeBuffer; i++ {
+			team.ProcessCommand(ann, "say hello")
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a stalled teammate held up the team")
+	}
+
+	for i := 0; i < noticeBuffer; i++ {
+		select {
+		case event := <-notices:
+			if event.Player != "Ann" || event.Type != EventSaid {
+				t.Fatalf("notice %d = %+v, want Ann saying hello", i, event)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only got %d notices", i)
+		}
+	}
+}
+
+func TestTeamLeaveHandsOverItems(t *testing.T) {
+	scenario := testScenario()
+	scenario.Items = append(scenario.Items, Item{ID: "pass", Name: "Pass", Description: "A guard's pass.", Hidden: true})
+	scenario.Actions = []Action{{
+		ID:          "find_pass",
+		Trigger:     ActionTrigger{Type: "examine", Target: "spoon"},
+		Effects:     []ActionEffect{{Type: "add_inventory", Target: "pass"}},
+		Message:     "The spoon was hiding a pass.",
+		OneTimeOnly: true,
+	}}
+	team := NewTeam(scenario)
+	ann, _ := team.Join("Ann", nil)
+	ben, _ := team.Join("Ben", nil)
+
+	for _, command := range []string{"take spoon", "look spoon"} {
+		if _, err := team.ProcessCommand(ann, command); err != nil {
+			t.Fatal(err)
+		}
+	}
+	team.Leave(ann)
+
+	players := team.Players()
+	if len(players) != 1 || len(players[0].Inventory) != 1 || players[0].Inventory[0] != "pass" {
+		t.Fatalf("after Ann left, players = %+v, want Ben holding the pass", players)
+	}
+	if _, err := team.ProcessCommand(ben, "take spoon"); err != nil {
+		t.Fatal(err)
+	}
+	if inventory := team.Players()[0].Inventory; len(inventory) != 2 {
+		t.Errorf("Ben's inventory = %v, want the spoon back in the cell to take", inventory)
+	}
+}
+
+func TestTeamCommandsKeepEffectsOnCarriedItems(t *testing.T) {
+	scenario := testScenario()
+	scenario.Items = append(scenario.Items, Item{ID: "lamp", Name: "Lamp", Description: "An oil lamp."})
+	scenario.Rooms[0].Items = append(scenario.Rooms[0].Items, "lamp")
+	scenario.Actions = []Action{{
+		ID:      "lamp_out",
+		Trigger: ActionTrigger{Type: "examine", Target: "lamp"},
+		Effects: []ActionEffect{{Type: "hide_item", Target: "spoon"}},
+		Message: "The lamp gutters out and the cell goes dark.",
+	}}
+	team := NewTeam(scenario)
+	ann, _ := team.Join("Ann", nil)
+	ben, _ := team.Join("Ben", nil)
+
+	for _, step := range []struct{ player, command string }{{ann, "take spoon"}, {ben, "take spoon"}, {ben, "look lamp"}} {
+		if _, err := team.ProcessCommand(step.player, step.command); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if players := team.Players(); len(players[1].Inventory) != 0 {
+		t.Errorf("Ben took %v from Ann's hands", players[1].Inventory)
+	}
+	if !team.Snapshot(ben).IsItemHidden("spoon") {
+		t.Error("Ben's command hid the spoon, but the hide was undone afterwards")
+	}
+}
+
+// blockingVoice holds every reply until it's released.
+type blockingVoice struct {
+	asked   chan struct{}
+	release chan struct{}
+}
+
+func (v *blockingVoice) VoiceNPC(req DialogueRequest) (string, error) {
+	v.asked <- struct{}{}
+	<-v.release
+	return "Mind the gate, friend.", nil
+}
+
+func TestTeamNPCVoiceDoesntHoldUpTeammates(t *testing.T) {
+	scenario := testScenario()
+	scenario.NPCs = []NPC{{
+		ID:          "guard",
+		Name:        "Guard",
+		Description: "A sleepy guard.",
+		Persona:     "Gruff but kind.",
+		Topics:      []DialogueTopic{{ID: "gate", Keywords: []string{"gate"}, Response: "The gate's that way."}},
+	}}
+	scenario.Rooms[0].NPCs = []string{"guard"}
+	team := NewTeam(scenario)
+	voice := &blockingVoice{asked: make(chan struct{}), release: make(chan struct{})}
+	team.SetDialogueVoice(voice)
+	ann, _ := team.Join("Ann", nil)
+	ben, _ := team.Join("Ben", nil)
+
+	replies := make(chan *CommandResult)
+	go func() {
+		result, _ := team.ProcessCommand(ann, "ask guard about gate")
+		replies <- result
+	}()
+	<-voice.asked
+
+	moved := make(chan struct{})
+	go func() {
+		team.ProcessCommand(ben, "go hall")
+		close(moved)
+	}()
+	select {
+	case <-moved:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Ben waited on the guard's reply to Ann")
+	}
+
+	close(voice.release)
+	if result := <-replies; !strings.Contains(result.Text(), "Mind the gate") {
+		t.Errorf("Ann heard %q, want the voiced reply", result.Text())
+	}
+}
+
+func TestTeamGameOverReachesEveryone(t *testing.T) {
+	team := NewTeam(testScenario())
+	notices := make(chan TeamEvent, noticeBuffer)
+	ann, _ := team.Join("Ann", nil)
+	ben, err := team.Join("Ben", func(event TeamEvent) { notices <- event })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if team.Finalists() != nil {
+		t.Error("finalists before the game ended")
+	}
+
+	for _, command := range []string{"solve open", "go hall", "solve swordfish"} {
+		if _, err := team.ProcessCommand(ann, command); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case <-team.Done():
+	default:
+		t.Fatal("Done not closed after the team escaped")
+	}
+	team.Leave(ben)
+	if finalists := team.Finalists(); len(finalists) != 2 {
+		t.Errorf("finalists = %+v, want Ann and Ben even after Ben left", finalists)
+	}
+	for {
+		select {
+		case event := <-notices:
+			if event.Type == EventGameWon {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Ben was never told the team escaped")
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	TimerModeRealTime = "real_time"
+	TimerModeTurns    = "turns"
+)
+
+type Timer struct {
+	Mode           string         `json:"mode"`  // "real_time" or "turns"
+	Limit          int            `json:"limit"` // seconds for real_time, moves for turns
+	Warnings       []TimerWarning `json:"warnings,omitempty"`
+	ExpiredMessage string         `json:"expired_message,omitempty"`
+}
+
+type TimerWarning struct {
+	Remaining int    `json:"remaining"` // seconds or moves left when the warning fires
+	Message   string `json:"message"`
+}
+
+// Elapsed returns the play time of the session, excluding any time the clock was paused.
+func (e *Engine) Elapsed() time.Duration {
+	if e.clockStarted.IsZero() {
+		return e.state.Elapsed
+	}
+	return e.state.Elapsed + time.Since(e.clockStarted)
+}
+
+// Pause stops the clock. It stops for good once the game is won or lost, so
+// the final time and score don't keep changing.
+func (e *Engine) Pause() {
+	if e.clockStarted.IsZero() {
+		return
+	}
+	e.state.Elapsed = e.Elapsed()
+	e.clockStarted = time.Time{}
+}
+
+func (e *Engine) Resume() {
+	if e.clockStarted.IsZero() && !e.state.GameWon && !e.state.GameLost {
+		e.clockStarted = time.Now()
+	}
+}
+
+func (e *Engine) HasTimer() bool {
+	timer := e.state.Scenario.Timer
+	return timer != nil && timer.Limit > 0
+}
+
+// TimerRemaining returns the seconds or moves left on the scenario timer.
+func (e *Engine) TimerRemaining() int {
+	if !e.HasTimer() {
+		return 0
+	}
+
+	timer := e.state.Scenario.Timer
+	var remaining int
+	if timer.Mode == TimerModeTurns {
+		remaining = timer.Limit - e.state.Moves
+	} else {
+		remaining = timer.Limit - int(e.Elapsed().Seconds())
+	}
+
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+func (e *Engine) TimerStatus() string {
+	if !e.HasTimer() {
+		return ""
+	}
+
+	remaining := e.TimerRemaining()
+	if e.state.Scenario.Timer.Mode == TimerModeTurns {
+		if remaining == 1 {
+			return "1 move left"
+		}
+		return fmt.Sprintf("%d moves left", remaining)
+	}
+	return fmt.Sprintf("%d:%02d", remaining/60, remaining%60)
+}
+
+// checkTimer fires any due warnings and expires the game once the limit is reached.
+func (e *Engine) checkTimer() {
+	if !e.HasTimer() || e.state.GameWon || e.state.GameLost {
+		return
+	}
+
+	timer := e.state.Scenario.Timer
+	remaining := e.TimerRemaining()
+
+	if remaining <= 0 {
+		e.expireTimer()
+		return
+	}
+
+	for i, warning := range timer.Warnings {
+		if remaining > warning.Remaining || e.hasShownTimerWarning(i) {
+			continue
+		}
+		e.state.TimerWarningsShown = append(e.state.TimerWarningsShown, i)
+		e.emit(Event{Type: EventTimerWarning, Message: warning.Message})
+	}
+}
+
+func (e *Engine) hasShownTimerWarning(index int) bool {
+	for _, shown := range e.state.TimerWarningsShown {
+		if shown == index {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveState pauses the clock and serializes the session so it can be resumed later.
+func (e *Engine) SaveState() ([]byte, error) {
+	e.Pause()
+	return json.MarshalIndent(e.state, "", "  ")
+}
+
+// LoadEngine restores a saved session. The clock stays paused until Resume is called.
+func LoadEngine(data []byte) (*Engine, error) {
+	var state GameState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	if state.Scenario == nil {
+		return nil, fmt.Errorf("saved game has no scenario")
+	}
+	if err := MigrateScenario(state.Scenario); err != nil {
+		return nil, err
+	}
+	if state.FailedAttempts == nil {
+		state.FailedAttempts = make(map[string]int)
+	}
+	state.WorldState.fillIn(state.Scenario)
+	// Saves from older versions didn't track visited rooms
+	state.visitRoom(state.CurrentRoom)
+	return &Engine{state: &state}, nil
+}
@@ -0,0 +1,79 @@
+package game
+
+import "testing"
+
+func TestTurnTimerWarnsAndExpires(t *testing.T) {
+	scenario := testScenario()
+	scenario.Timer = &Timer{
+		Mode:           TimerModeTurns,
+		Limit:          3,
+		Warnings:       []TimerWarning{{Remaining: 1, Message: "Last move!"}},
+		ExpiredMessage: "Out of moves.",
+	}
+	engine := NewEngine(scenario)
+
+	result, err := engine.ProcessCommand("look")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Has(EventTimerWarning) {
+		t.Error("warned with two moves left")
+	}
+	result, err = engine.ProcessCommand("look")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Has(EventTimerWarning) {
+		t.Error("expected a warning with one move left")
+	}
+	if status := engine.TimerStatus(); status != "1 move left" {
+		t.Errorf("status = %q, want %q", status, "1 move left")
+	}
+
+	result, err = engine.ProcessCommand("look")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !engine.IsGameLost() || !result.Has(EventGameLost) {
+		t.Fatal("expected the game to be lost when the moves ran out")
+	}
+	if result.Has(EventTimerWarning) {
+		t.Error("warned again after the warning was shown")
+	}
+}
+
+func TestUntimedScenarioNeverExpires(t *testing.T) {
+	engine := NewEngine(testScenario())
+	for i := 0; i < 50; i++ {
+		play(t, engine, "look")
+	}
+	if engine.HasTimer() || engine.IsGameLost() || engine.TimerStatus() != "" {
+		t.Error("scenario without a timer should never run out of time")
+	}
+}
+
+func TestApplyDifficultyTimer(t *testing.T) {
+	for _, level := range DifficultyLevels {
+		scenario := testScenario()
+		scenario.ApplyDifficulty(level)
+		if got := scenario.Timer != nil; got != level.Timed {
+			t.Errorf("%s: timed = %v, want %v", level.Name, got, level.Timed)
+		}
+	}
+
+	scenario := testScenario()
+	scenario.Timer = &Timer{Mode: TimerModeTurns, Limit: 100, Warnings: []TimerWarning{{Remaining: 60}, {Remaining: 10}}}
+	easy, _ := Difficulty(DifficultyEasy)
+	scenario.ApplyDifficulty(easy)
+	if scenario.Timer.Limit != 150 {
+		t.Errorf("easy limit = %d, want 150", scenario.Timer.Limit)
+	}
+
+	scenario = testScenario()
+	scenario.Timer = &Timer{Mode: TimerModeTurns, Limit: 100, Warnings: []TimerWarning{{Remaining: 60}, {Remaining: 10}}}
+	expert, _ := Difficulty(DifficultyExpert)
+	scenario.ApplyDifficulty(expert)
+	if scenario.Timer.Limit != 50 || len(scenario.Timer.Warnings) != 1 {
+		t.Errorf("expert limit = %d with %d warnings, want 50 with 1", scenario.Timer.Limit, len(scenario.Timer.Warnings))
+	}
+}
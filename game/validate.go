@@ -0,0 +1,312 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Validate checks a scenario for broken references and incomplete definitions
+// and returns a description of each problem found.
+func (s *Scenario) Validate() []string {
+	var problems []string
+	report := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if len(s.Rooms) == 0 {
+		report("the scenario has no rooms")
+	}
+	if len(s.Puzzles) == 0 {
+		report("the scenario has no puzzles, so it can't be won")
+	}
+
+	rooms := make(map[string]bool)
+	items := make(map[string]bool)
+	puzzles := make(map[string]bool)
+	actions := make(map[string]bool)
+	npcs := make(map[string]bool)
+	unique := func(ids map[string]bool, kind, id string) {
+		if id == "" {
+			report("a %s has no ID", kind)
+		} else if ids[id] {
+			report("%s ID %q is used more than once", kind, id)
+		}
+		ids[id] = true
+	}
+
+	for _, room := range s.Rooms {
+		unique(rooms, "room", room.ID)
+	}
+	for _, item := range s.Items {
+		unique(items, "item", item.ID)
+	}
+	for _, puzzle := range s.Puzzles {
+		unique(puzzles, "puzzle", puzzle.ID)
+	}
+	for _, action := range s.Actions {
+		unique(actions, "action", action.ID)
+	}
+	for _, npc := range s.NPCs {
+		unique(npcs, "npc", npc.ID)
+	}
+
+	placedItems := make(map[string]bool)
+	placedPuzzles := make(map[string]bool)
+	for _, room := range s.Rooms {
+		for _, exit := range room.Exits {
+			if !rooms[exit] {
+				report("room %q has an exit to unknown room %q", room.ID, exit)
+			}
+		}
+		for _, item := range room.Items {
+			if !items[item] {
+				report("room %q contains unknown item %q", room.ID, item)
+			}
+			placedItems[item] = true
+		}
+		for _, puzzle := range room.Puzzles {
+			if !puzzles[puzzle] {
+				report("room %q contains unknown puzzle %q", room.ID, puzzle)
+			}
+			placedPuzzles[puzzle] = true
+		}
+		for _, npc := range room.NPCs {
+			if !npcs[npc] {
+				report("room %q contains unknown npc %q", room.ID, npc)
+			}
+		}
+		if room.UnlockKey != "" && !items[room.UnlockKey] {
+			report("room %q is unlocked by unknown item %q", room.ID, room.UnlockKey)
+		}
+	}
+
+	for _, puzzle := range s.Puzzles {
+		if !placedPuzzles[puzzle.ID] {
+			report("puzzle %q isn't in any room", puzzle.ID)
+		}
+		for _, item := range puzzle.RequiredItems {
+			if !items[item] {
+				report("puzzle %q requires unknown item %q", puzzle.ID, item)
+			}
+		}
+
+		switch puzzle.Type {
+		case "", PuzzleTypeAnswer, PuzzleTypeCombination, PuzzleTypeKeypad, PuzzleTypeRiddle, PuzzleTypeOrdering:
+			if strings.TrimSpace(puzzle.Solution) == "" {
+				report("puzzle %q has no solution", puzzle.ID)
+			} else if puzzle.solutionKey() == "" {
+				report("puzzle %q's solution %q has nothing a player could type", puzzle.ID, puzzle.Solution)
+			}
+		case PuzzleTypeSequence:
+			if len(puzzle.Steps) == 0 {
+				report("sequence puzzle %q has no steps", puzzle.ID)
+			}
+		case PuzzleTypeCipher:
+			if puzzle.Cipher == nil {
+				report("cipher puzzle %q has no cipher", puzzle.ID)
+			} else if puzzle.Cipher.Method == CipherSubstitution && len(puzzle.Cipher.Key) != 26 {
+				report("cipher puzzle %q needs a 26-letter substitution key", puzzle.ID)
+			}
+			if strings.TrimSpace(puzzle.Solution) == "" {
+				report("puzzle %q has no solution", puzzle.ID)
+			} else if puzzle.solutionKey() == "" {
+				report("cipher puzzle %q's solution %q has no letters to encrypt", puzzle.ID, puzzle.Solution)
+			}
+		default:
+			report("puzzle %q has unknown type %q", puzzle.ID, puzzle.Type)
+		}
+	}
+
+	for _, item := range s.Items {
+		if !placedItems[item.ID] && !s.itemIsGiven(item.ID) {
+			report("item %q isn't in any room and nothing gives it to the player", item.ID)
+		}
+	}
+
+	checkConditions := func(owner string, conditions []ActionCondition) {
+		for _, condition := range conditions {
+			switch condition.Type {
+			case "has_item":
+				if !items[condition.Value] {
+					report("%s needs unknown item %q", owner, condition.Value)
+				}
+			case "in_room":
+				if !rooms[condition.Value] {
+					report("%s needs unknown room %q", owner, condition.Value)
+				}
+			case "puzzle_solved":
+				if !puzzles[condition.Value] {
+					report("%s needs unknown puzzle %q", owner, condition.Value)
+				}
+			case "action_performed":
+				if !actions[condition.Value] {
+					report("%s needs unknown action %q", owner, condition.Value)
+				}
+			case "topic_discussed":
+				if npc, _, _ := strings.Cut(condition.Value, "."); !npcs[npc] {
+					report("%s needs a topic of unknown npc %q", owner, npc)
+				}
+			default:
+				report("%s has unknown condition type %q", owner, condition.Type)
+			}
+		}
+	}
+	checkEffects := func(owner string, effects []ActionEffect) {
+		for _, effect := range effects {
+			switch effect.Type {
+			case "reveal_item", "hide_item", "add_inventory", "remove_inventory":
+				if !items[effect.Target] {
+					report("%s affects unknown item %q", owner, effect.Target)
+				}
+			case "unlock_room":
+				if !rooms[effect.Target] {
+					report("%s unlocks unknown room %q", owner, effect.Target)
+				}
+			default:
+				report("%s has unknown effect type %q", owner, effect.Type)
+			}
+		}
+	}
+
+	for _, action := range s.Actions {
+		owner := fmt.Sprintf("action %q", action.ID)
+		switch action.Trigger.Type {
+		case "examine", "use", "use_with", "take":
+			if action.Trigger.Target == "" {
+				report("%s has no trigger target", owner)
+			}
+		case "solve":
+			if !puzzles[action.Trigger.Target] {
+				report("%s is triggered by unknown puzzle %q", owner, action.Trigger.Target)
+			}
+		default:
+			report("%s has unknown trigger type %q", owner, action.Trigger.Type)
+		}
+		checkConditions(owner, action.Conditions)
+		checkEffects(owner, action.Effects)
+	}
+
+	for _, npc := range s.NPCs {
+		for _, topic := range npc.Topics {
+			owner := fmt.Sprintf("topic %q of npc %q", topic.ID, npc.ID)
+			checkConditions(owner, topic.Conditions)
+			checkEffects(owner, topic.Effects)
+		}
+		for _, trade := range npc.Trades {
+			owner := fmt.Sprintf("a trade of npc %q", npc.ID)
+			if !items[trade.Wants] {
+				report("%s wants unknown item %q", owner, trade.Wants)
+			}
+			if trade.Gives != "" && !items[trade.Gives] {
+				report("%s gives unknown item %q", owner, trade.Gives)
+			}
+			checkConditions(owner, trade.Conditions)
+			checkEffects(owner, trade.Effects)
+		}
+	}
+
+	for id := range s.Hints {
+		if !rooms[id] {
+			report("hint for unknown room %q", id)
+		}
+	}
+	for _, hint := range s.ProgressiveHints {
+		if !rooms[hint.Context] && !puzzles[hint.Context] {
+			report("progressive hint %q is for unknown room or puzzle %q", hint.HintText, hint.Context)
+		}
+		for _, trigger := range hint.Triggers {
+			switch trigger.Type {
+			case "failed_attempts", "time_spent", "commands_tried":
+			default:
+				report("progressive hint %q has unknown trigger type %q", hint.HintText, trigger.Type)
+			}
+		}
+	}
+
+	if s.Difficulty != "" {
+		if _, err := Difficulty(s.Difficulty); err != nil {
+			report("%v", err)
+		}
+	}
+	if s.Timer != nil {
+		if s.Timer.Mode != TimerModeRealTime && s.Timer.Mode != TimerModeTurns {
+			report("timer has unknown mode %q", s.Timer.Mode)
+		}
+		if s.Timer.Limit <= 0 {
+			report("timer has no limit")
+		}
+	}
+	for _, fail := range s.FailConditions {
+		switch fail.Type {
+		case FailTimerExpired:
+			if s.Timer == nil {
+				report("fail condition %q needs a timer", fail.ID)
+			}
+		case FailFailedAttempts:
+			if fail.Target != "" && !puzzles[fail.Target] {
+				report("fail condition %q watches unknown puzzle %q", fail.ID, fail.Target)
+			}
+		case FailActionTriggered:
+			if !actions[fail.Target] {
+				report("fail condition %q watches unknown action %q", fail.ID, fail.Target)
+			}
+		default:
+			report("fail condition %q has unknown type %q", fail.ID, fail.Type)
+		}
+	}
+
+	if s.Scoring != nil {
+		for _, itemID := range s.Scoring.Secrets {
+			if !items[itemID] {
+				report("scoring counts unknown item %q as a secret", itemID)
+			}
+		}
+		for _, value := range s.Scoring.pointValues(&PointValues{}) {
+			if value.custom != nil && *value.custom < 0 {
+				report("scoring sets %s to %d; point values can't be negative", value.name, *value.custom)
+			}
+		}
+	}
+	achievements := make(map[string]bool)
+	for _, achievement := range s.Achievements {
+		unique(achievements, "achievement", achievement.ID)
+		owner := fmt.Sprintf("achievement %q", achievement.ID)
+		if len(achievement.Conditions) == 0 && !achievement.OnEscape && achievement.MaxHints == nil && achievement.MaxMoves == nil {
+			report("%s has no conditions, so it's earned straight away", owner)
+		}
+		checkConditions(owner, achievement.Conditions)
+	}
+
+	return problems
+}
+
+// itemIsGiven reports whether an action, topic or trade hands the item to the player.
+func (s *Scenario) itemIsGiven(itemID string) bool {
+	gives := func(effects []ActionEffect) bool {
+		for _, effect := range effects {
+			if effect.Type == "add_inventory" && effect.Target == itemID {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, action := range s.Actions {
+		if gives(action.Effects) {
+			return true
+		}
+	}
+	for _, npc := range s.NPCs {
+		for _, topic := range npc.Topics {
+			if gives(topic.Effects) {
+				return true
+			}
+		}
+		for _, trade := range npc.Trades {
+			if trade.Gives == itemID || gives(trade.Effects) {
+				return true
+			}
+		}
+	}
+	return false
+}
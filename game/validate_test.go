@@ -0,0 +1,147 @@
+package game
+
+import "testing"
+
+func TestValidateReportsEachProblem(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(s *Scenario)
+		want   string
+	}{
+		{"no rooms", func(s *Scenario) { s.Rooms = nil }, "the scenario has no rooms"},
+		{"no puzzles", func(s *Scenario) { s.Puzzles = nil }, "the scenario has no puzzles, so it can't be won"},
+		{"missing ID", func(s *Scenario) { s.Items[0].ID = "" }, "a item has no ID"},
+		{"duplicate ID", func(s *Scenario) { s.Rooms[1].ID = "cell" }, `room ID "cell" is used more than once`},
+
+		{"unknown exit", func(s *Scenario) { s.Rooms[0].Exits = []string{"attic"} }, `room "cell" has an exit to unknown room "attic"`},
+		{"unknown room item", func(s *Scenario) { s.Rooms[0].Items = []string{"fork"} }, `room "cell" contains unknown item "fork"`},
+		{"unknown room puzzle", func(s *Scenario) { s.Rooms[1].Puzzles = []string{"gate", "moat"} }, `room "hall" contains unknown puzzle "moat"`},
+		{"unknown room npc", func(s *Scenario) { s.Rooms[0].NPCs = []string{"guard"} }, `room "cell" contains unknown npc "guard"`},
+		{"unknown unlock key", func(s *Scenario) { s.Rooms[1].UnlockKey = "key" }, `room "hall" is unlocked by unknown item "key"`},
+
+		{"unplaced puzzle", func(s *Scenario) { s.Rooms[1].Puzzles = nil }, `puzzle "gate" isn't in any room`},
+		{"unknown required item", func(s *Scenario) { s.Puzzles[0].RequiredItems = []string{"key"} }, `puzzle "door" requires unknown item "key"`},
+		{"no solution", func(s *Scenario) { s.Puzzles[0].Solution = " " }, `puzzle "door" has no solution`},
+		{"untypeable solution", func(s *Scenario) { s.Puzzles[0].Solution = "the" }, `puzzle "door"'s solution "the" has nothing a player could type`},
+		{"sequence without steps", func(s *Scenario) { s.Puzzles[0].Type = PuzzleTypeSequence }, `sequence puzzle "door" has no steps`},
+		{"cipher without cipher", func(s *Scenario) { s.Puzzles[0].Type = PuzzleTypeCipher }, `cipher puzzle "door" has no cipher`},
+		{"short substitution key", func(s *Scenario) {
+			s.Puzzles[0].Type = PuzzleTypeCipher
+			s.Puzzles[0].Cipher = &Cipher{Method: CipherSubstitution, Key: "abc"}
+		}, `cipher puzzle "door" needs a 26-letter substitution key`},
+		{"cipher without letters", func(s *Scenario) {
+			s.Puzzles[0].Type = PuzzleTypeCipher
+			s.Puzzles[0].Cipher = &Cipher{Method: CipherCaesar, Shift: 3}
+			s.Puzzles[0].Solution = "42"
+		}, `cipher puzzle "door"'s solution "42" has no letters to encrypt`},
+		{"unknown puzzle type", func(s *Scenario) { s.Puzzles[0].Type = "jigsaw" }, `puzzle "door" has unknown type "jigsaw"`},
+
+		{"unreachable item", func(s *Scenario) { s.Rooms[0].Items = nil }, `item "spoon" isn't in any room and nothing gives it to the player`},
+
+		{"condition on unknown item", func(s *Scenario) { s.Actions = []Action{examineSpoon(ActionCondition{Type: "has_item", Value: "key"})} }, `action "bend" needs unknown item "key"`},
+		{"condition on unknown room", func(s *Scenario) {
+			s.Actions = []Action{examineSpoon(ActionCondition{Type: "in_room", Value: "attic"})}
+		}, `action "bend" needs unknown room "attic"`},
+		{"condition on unknown puzzle", func(s *Scenario) {
+			s.Actions = []Action{examineSpoon(ActionCondition{Type: "puzzle_solved", Value: "moat"})}
+		}, `action "bend" needs unknown puzzle "moat"`},
+		{"condition on unknown action", func(s *Scenario) {
+			s.Actions = []Action{examineSpoon(ActionCondition{Type: "action_performed", Value: "dig"})}
+		}, `action "bend" needs unknown action "dig"`},
+		{"condition on unknown npc", func(s *Scenario) {
+			s.Actions = []Action{examineSpoon(ActionCondition{Type: "topic_discussed", Value: "guard.gate"})}
+		}, `action "bend" needs a topic of unknown npc "guard"`},
+		{"unknown condition type", func(s *Scenario) { s.Actions = []Action{examineSpoon(ActionCondition{Type: "is_raining"})} }, `action "bend" has unknown condition type "is_raining"`},
+
+		{"effect on unknown item", func(s *Scenario) { s.Actions = []Action{spoonEffect(ActionEffect{Type: "reveal_item", Target: "key"})} }, `action "bend" affects unknown item "key"`},
+		{"effect on unknown room", func(s *Scenario) {
+			s.Actions = []Action{spoonEffect(ActionEffect{Type: "unlock_room", Target: "attic"})}
+		}, `action "bend" unlocks unknown room "attic"`},
+		{"unknown effect type", func(s *Scenario) { s.Actions = []Action{spoonEffect(ActionEffect{Type: "explode"})} }, `action "bend" has unknown effect type "explode"`},
+
+		{"action without target", func(s *Scenario) { s.Actions = []Action{{ID: "bend", Trigger: ActionTrigger{Type: "use"}}} }, `action "bend" has no trigger target`},
+		{"action on unknown puzzle", func(s *Scenario) {
+			s.Actions = []Action{{ID: "bend", Trigger: ActionTrigger{Type: "solve", Target: "moat"}}}
+		}, `action "bend" is triggered by unknown puzzle "moat"`},
+		{"unknown trigger type", func(s *Scenario) {
+			s.Actions = []Action{{ID: "bend", Trigger: ActionTrigger{Type: "sniff", Target: "spoon"}}}
+		}, `action "bend" has unknown trigger type "sniff"`},
+
+		{"topic condition", func(s *Scenario) {
+			addGuard(s).Topics = []DialogueTopic{{ID: "gate", Conditions: []ActionCondition{{Type: "has_item", Value: "key"}}}}
+		}, `topic "gate" of npc "guard" needs unknown item "key"`},
+		{"trade wants unknown item", func(s *Scenario) { addGuard(s).Trades = []Trade{{Wants: "coin"}} }, `a trade of npc "guard" wants unknown item "coin"`},
+		{"trade gives unknown item", func(s *Scenario) { addGuard(s).Trades = []Trade{{Wants: "spoon", Gives: "key"}} }, `a trade of npc "guard" gives unknown item "key"`},
+
+		{"hint for unknown room", func(s *Scenario) { s.Hints["attic"] = "Look up." }, `hint for unknown room "attic"`},
+		{"progressive hint context", func(s *Scenario) { s.ProgressiveHints[0].Context = "moat" }, `progressive hint "The answer is a kind of door." is for unknown room or puzzle "moat"`},
+		{"progressive hint trigger", func(s *Scenario) { s.ProgressiveHints[0].Triggers[0].Type = "boredom" }, `progressive hint "The answer is a kind of door." has unknown trigger type "boredom"`},
+
+		{"unknown difficulty", func(s *Scenario) { s.Difficulty = "brutal" }, `unknown difficulty "brutal" (choose easy, normal, hard, expert)`},
+		{"unknown timer mode", func(s *Scenario) { s.Timer = &Timer{Mode: "hourglass", Limit: 10} }, `timer has unknown mode "hourglass"`},
+		{"timer without limit", func(s *Scenario) { s.Timer = &Timer{Mode: TimerModeTurns} }, "timer has no limit"},
+
+		{"fail condition without timer", func(s *Scenario) {
+			s.FailConditions = []FailCondition{{ID: "late", Type: FailTimerExpired}}
+		}, `fail condition "late" needs a timer`},
+		{"fail condition on unknown puzzle", func(s *Scenario) {
+			s.FailConditions = []FailCondition{{ID: "jammed", Type: FailFailedAttempts, Target: "moat", Threshold: 3}}
+		}, `fail condition "jammed" watches unknown puzzle "moat"`},
+		{"fail condition on unknown action", func(s *Scenario) {
+			s.FailConditions = []FailCondition{{ID: "alarm", Type: FailActionTriggered, Target: "dig"}}
+		}, `fail condition "alarm" watches unknown action "dig"`},
+		{"unknown fail condition type", func(s *Scenario) {
+			s.FailConditions = []FailCondition{{ID: "bored", Type: "boredom"}}
+		}, `fail condition "bored" has unknown type "boredom"`},
+
+		{"unknown secret", func(s *Scenario) { s.Scoring = &Scoring{Secrets: []string{"gem"}} }, `scoring counts unknown item "gem" as a secret`},
+		{"negative points", func(s *Scenario) {
+			penalty := -5
+			s.Scoring = &Scoring{HintPenalty: &penalty}
+		}, "scoring sets hint_penalty to -5; point values can't be negative"},
+
+		{"duplicate achievement", func(s *Scenario) {
+			s.Achievements = []Achievement{{ID: "quick", OnEscape: true}, {ID: "quick", OnEscape: true}}
+		}, `achievement ID "quick" is used more than once`},
+		{"achievement without conditions", func(s *Scenario) { s.Achievements = []Achievement{{ID: "free"}} }, `achievement "free" has no conditions, so it's earned straight away`},
+		{"achievement condition", func(s *Scenario) {
+			s.Achievements = []Achievement{{ID: "lucky", Conditions: []ActionCondition{{Type: "has_item", Value: "clover"}}}}
+		}, `achievement "lucky" needs unknown item "clover"`},
+	}
+
+	if problems := testScenario().Validate(); len(problems) != 0 {
+		t.Fatalf("test scenario has problems: %v", problems)
+	}
+	for _, test := range tests {
+		scenario := testScenario()
+		test.change(scenario)
+
+		problems := scenario.Validate()
+		found := false
+		for _, problem := range problems {
+			if problem == test.want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s: problems = %q, want %q among them", test.name, problems, test.want)
+		}
+	}
+}
+
+// examineSpoon is an action on the spoon that needs a condition to be met.
+func examineSpoon(condition ActionCondition) Action {
+	return Action{ID: "bend", Trigger: ActionTrigger{Type: "examine", Target: "spoon"}, Conditions: []ActionCondition{condition}}
+}
+
+// spoonEffect is an action on the spoon with a single effect.
+func spoonEffect(effect ActionEffect) Action {
+	return Action{ID: "bend", Trigger: ActionTrigger{Type: "examine", Target: "spoon"}, Effects: []ActionEffect{effect}}
+}
+
+// addGuard puts a guard in the cell and returns it for the test to fill in.
+func addGuard(s *Scenario) *NPC {
+	s.NPCs = append(s.NPCs, NPC{ID: "guard", Name: "Guard", Description: "A sleepy guard."})
+	s.Rooms[0].NPCs = []string{"guard"}
+	return &s.NPCs[len(s.NPCs)-1]
+}
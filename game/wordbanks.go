@@ -0,0 +1,355 @@
+package game
+
+import (
+	"hash/fnv"
+	"strings"
+)
+
+// Themes are the built-in themes offered when the player doesn't pick one.
+// Each has a word bank for the procedural generator.
+var Themes = []string{
+	"Haunted Victorian Mansion",
+	"Abandoned Space Station",
+	"Ancient Egyptian Tomb",
+	"Mad Scientist's Laboratory",
+	"Pirate Ship",
+	"Time Machine Malfunction",
+	"Zombie Apocalypse Bunker",
+	"Magic Academy",
+	"Bank Heist Gone Wrong",
+	"Underwater Research Base",
+}
+
+type wordBank struct {
+	Setting   string
+	Backstory string
+	Rooms     []bankEntry
+	Features  []string // things to search; last words must be distinct
+	Keys      []string
+	Notes     []string // clue items
+	Curios    []bankEntry
+	Locks     []string // names for combination locks and keypads
+	Words     []string // cipher plaintexts
+	Symbols   []string // pieces for ordering puzzles
+	Riddles   []bankRiddle
+	Escape    string
+	Expired   string
+}
+
+type bankEntry struct {
+	Name        string
+	Description string
+}
+
+type bankRiddle struct {
+	Question string
+	Answer   string
+	Answers  []string
+}
+
+var wordBanks = map[string]wordBank{
+	"Haunted Victorian Mansion": {
+		Setting:   "A decaying Victorian mansion on the edge of a fog-bound moor",
+		Backstory: "You came to investigate the disappearance of the Ashcombe family. The front door slammed and bolted itself behind you, and the candles lit on their own. Whatever haunts this house wants you to earn your way out.",
+		Rooms: []bankEntry{
+			{"Grand Foyer", "A sweeping staircase rises into darkness. Dust sheets cover the furniture and a chandelier sways without a breeze."},
+			{"Portrait Gallery", "Stern ancestors glare down from gilded frames. Their eyes seem to follow you along the creaking floorboards."},
+			{"Dusty Library", "Shelves groan under mouldering books. A cold draught stirs the pages of a volume left open on a lectern."},
+			{"Conservatory", "Dead orchids droop in cracked pots beneath a glass ceiling streaked with rain."},
+			{"Servants' Kitchen", "Copper pans hang over a cold iron range. A bell board on the wall twitches now and then."},
+		},
+		Features: []string{"grandfather clock", "velvet armchair", "writing bureau", "marble fireplace", "cracked mirror", "music box", "steamer trunk", "iron range"},
+		Keys:     []string{"tarnished silver key", "skeleton key", "crypt key"},
+		Notes:    []string{"yellowed letter", "diary page", "funeral card", "seance notes"},
+		Curios: []bankEntry{
+			{"porcelain doll", "A porcelain doll with a cracked face. You could swear it was facing the other way a moment ago."},
+			{"mourning locket", "A black locket holding a lock of pale hair."},
+			{"candle stub", "A stub of tallow candle, still warm."},
+			{"calling card", "A calling card engraved with the name 'Lady Ashcombe'."},
+		},
+		Locks:   []string{"Brass Padlock", "Safe Dial", "Chapel Door Lock"},
+		Words:   []string{"midnight", "ashcombe", "requiem", "lantern"},
+		Symbols: []string{"raven", "rose", "skull", "moon", "candle"},
+		Riddles: []bankRiddle{
+			{"Carved above the door: 'The more of me you take, the more you leave behind. What am I?'", "footsteps", []string{"steps", "footprints"}},
+			{"A voice whispers: 'I have no voice, yet I speak to you; I tell of all things in the world that people do. What am I?'", "a book", []string{"book", "books"}},
+		},
+		Escape:  "Break the curse of Ashcombe Manor and step out into the dawn.",
+		Expired: "The candles gutter out one by one. When the last one dies, you hear the house sigh - it has a new resident.",
+	},
+	"Abandoned Space Station": {
+		Setting:   "Orbital research station Kepler-9, drifting silently above a gas giant",
+		Backstory: "Your shuttle docked with Kepler-9 after it stopped answering hails. Now the docking clamps won't release, the crew is gone, and life support is failing. Find a way to the escape pod.",
+		Rooms: []bankEntry{
+			{"Docking Bay", "Emergency lights strobe across scorched bulkheads. Your shuttle hangs uselessly in its clamps beyond a viewport."},
+			{"Crew Quarters", "Bunks float with loose blankets in the failing gravity. Personal effects drift lazily in the air."},
+			{"Hydroponics Lab", "Withered plants hang from nutrient racks. Condensation beads on the glass of the grow tanks."},
+			{"Command Deck", "Dead consoles ring the captain's chair. A star chart flickers on the main display."},
+			{"Reactor Core", "The reactor hums behind a shield of thick glass, bathing everything in a sickly blue glow."},
+		},
+		Features: []string{"supply locker", "maintenance hatch", "oxygen console", "sleep pod", "cargo crate", "navigation terminal", "coolant pipe", "med bay cabinet"},
+		Keys:     []string{"security keycard", "engineer's access chip", "captain's override key"},
+		Notes:    []string{"crew logbook", "data slate", "maintenance manifest", "torn checklist"},
+		Curios: []bankEntry{
+			{"floating wrench", "A heavy wrench tumbling slowly end over end."},
+			{"mission patch", "An embroidered patch reading 'KEPLER-9: AD ASTRA'."},
+			{"empty ration pack", "A foil ration pack, licked clean."},
+			{"cracked helmet", "A spacesuit helmet with a spiderweb crack across the visor."},
+		},
+		Locks:   []string{"Airlock Keypad", "Reactor Access Panel", "Escape Pod Console"},
+		Words:   []string{"orbit", "gravity", "nebula", "airlock"},
+		Symbols: []string{"mercury", "venus", "earth", "mars", "jupiter"},
+		Riddles: []bankRiddle{
+			{"A message blinks on the screen: 'I am always coming but never arrive. What am I?'", "tomorrow", nil},
+			{"The console reads: 'What has a ring but no finger?'", "a telephone", []string{"telephone", "phone", "saturn"}},
+		},
+		Escape:  "Reach the escape pod and launch before life support fails.",
+		Expired: "The oxygen alarm falls silent. Frost creeps across your visor as the station claims another crew member.",
+	},
+	"Ancient Egyptian Tomb": {
+		Setting:   "The sealed tomb of a forgotten pharaoh beneath the Valley of the Kings",
+		Backstory: "Your expedition's torchlight was the first in three thousand years to touch these walls. Then the entrance stone slid shut. The only way out is through the pharaoh's trials.",
+		Rooms: []bankEntry{
+			{"Antechamber", "Hieroglyphs crowd every wall. Gilded statues of jackal-headed guardians flank a sealed doorway."},
+			{"Hall of Offerings", "Clay jars and baskets of withered grain are piled around a stone altar."},
+			{"Chamber of the Scarab", "A vast golden scarab is carved into the ceiling, its wings spread over a floor of cracked tiles."},
+			{"Burial Chamber", "A massive sarcophagus rests at the centre of the room, its lid painted with the pharaoh's serene face."},
+			{"Treasury", "Gold glitters in the torchlight: chariots, thrones and chests stacked to the ceiling."},
+		},
+		Features: []string{"canopic chest", "stone altar", "guardian statue", "painted sarcophagus", "offering basket", "papyrus shelf", "sand pit", "cedar coffer"},
+		Keys:     []string{"golden ankh", "scarab amulet", "eye of horus"},
+		Notes:    []string{"papyrus scroll", "scribe's tablet", "ostracon shard", "burial inscription"},
+		Curios: []bankEntry{
+			{"clay ushabti", "A small clay figure meant to serve the pharaoh in the afterlife."},
+			{"dried lotus", "A brittle lotus flower, still faintly fragrant."},
+			{"bronze mirror", "A polished bronze mirror that catches the torchlight."},
+			{"faience bead", "A blue-green bead, smooth as glass."},
+		},
+		Locks:   []string{"Sun Dial Lock", "Obelisk Seal", "Star Wheel"},
+		Words:   []string{"osiris", "anubis", "pharaoh", "papyrus"},
+		Symbols: []string{"ankh", "falcon", "sun", "serpent", "reed"},
+		Riddles: []bankRiddle{
+			{"The sphinx's carving asks: 'What walks on four legs in the morning, two at noon and three in the evening?'", "man", []string{"a man", "human", "a human", "person"}},
+			{"Written in gold: 'The one who makes it sells it, the one who buys it never uses it, the one who uses it never sees it. What is it?'", "a coffin", []string{"coffin", "sarcophagus"}},
+		},
+		Escape:  "Pass the pharaoh's trials and climb back into the desert sun.",
+		Expired: "Your torch sputters out. In the darkness, the sound of shifting sand fills the tomb.",
+	},
+	"Mad Scientist's Laboratory": {
+		Setting:   "The hidden laboratory of Dr. Vesper Crane, deep beneath a shuttered asylum",
+		Backstory: "You answered an ad for a 'research assistant, well paid'. The elevator doors sealed behind you and a recorded voice announced that your first task is to prove your intellect - or become the next experiment.",
+		Rooms: []bankEntry{
+			{"Reception", "A cheerful welcome banner hangs crookedly over a desk covered in unsigned consent forms."},
+			{"Chemistry Lab", "Beakers bubble over blue flames. Something green drips steadily from a cracked retort."},
+			{"Specimen Room", "Jars line the walls, their murky contents pressed against the glass."},
+			{"Tesla Chamber", "Two towering coils crackle with arcs of violet lightning."},
+			{"Dr. Crane's Office", "Chalkboards covered in frantic equations surround a desk piled with half-eaten sandwiches."},
+		},
+		Features: []string{"fume hood", "specimen jar", "filing cabinet", "chalkboard", "operating table", "centrifuge", "lead-lined box", "coat rack"},
+		Keys:     []string{"lab keycard", "rusty lever handle", "cryo vault key"},
+		Notes:    []string{"lab notebook", "coffee-stained memo", "experiment log", "sticky note"},
+		Curios: []bankEntry{
+			{"rubber glove", "A single yellow rubber glove. It twitches."},
+			{"goggles", "Safety goggles with one lens melted."},
+			{"pickled eyeball", "An eyeball floating in a small jar. It appears to be looking at you."},
+			{"broken test tube", "A test tube snapped cleanly in half, crusted with purple residue."},
+		},
+		Locks:   []string{"Cryo Vault Keypad", "Chemical Safe", "Elevator Override"},
+		Words:   []string{"eureka", "voltage", "catalyst", "mutation"},
+		Symbols: []string{"hydrogen", "helium", "lithium", "carbon", "oxygen"},
+		Riddles: []bankRiddle{
+			{"A chalkboard reads: 'I have cities but no houses, forests but no trees, water but no fish. What am I?'", "a map", []string{"map"}},
+			{"A note taped to the door: 'What gets wetter the more it dries?'", "a towel", []string{"towel"}},
+		},
+		Escape:  "Outwit Dr. Crane's tests and ride the elevator back to the surface.",
+		Expired: "The lights flicker and the speakers crackle: 'Disappointing. Prepare the next subject.'",
+	},
+	"Pirate Ship": {
+		Setting:   "The Crimson Gull, a pirate galleon drifting through a moonless night",
+		Backstory: "You were shanghaied in a dockside tavern and woke in the brig. The crew has vanished in the storm, the ship is taking on water, and Captain Redfeather's treasure is somewhere aboard.",
+		Rooms: []bankEntry{
+			{"The Brig", "Damp iron bars, a puddle of seawater and the smell of old rope. The ship groans around you."},
+			{"Gun Deck", "Cannons strain against their ropes as the deck pitches. Powder kegs are stacked in the corner."},
+			{"Galley", "Pots swing from hooks over a cold stove. A half-eaten loaf of bread sits on the table."},
+			{"Captain's Cabin", "Charts are pinned over a heavy oak desk. The stern windows show nothing but black water."},
+			{"Cargo Hold", "Crates and barrels shift with the swell. Water sloshes around your ankles."},
+		},
+		Features: []string{"powder keg", "sea chest", "hammock", "chart table", "rum barrel", "ship's wheel", "coil of rope", "lantern hook"},
+		Keys:     []string{"brass cabin key", "bosun's whistle", "captain's signet"},
+		Notes:    []string{"water-stained map", "ship's log", "message in a bottle", "crew roster"},
+		Curios: []bankEntry{
+			{"parrot feather", "A bright red feather from the captain's parrot."},
+			{"tin cup", "A dented tin cup that smells of rum."},
+			{"doubloon", "A single gold doubloon stamped with a skull."},
+			{"glass eye", "A glass eye. You decide not to think about whose it was."},
+		},
+		Locks:   []string{"Treasure Chest Lock", "Armoury Padlock", "Captain's Strongbox"},
+		Words:   []string{"anchor", "plunder", "kraken", "compass"},
+		Symbols: []string{"anchor", "skull", "cutlass", "parrot", "star"},
+		Riddles: []bankRiddle{
+			{"Carved into the mast: 'What has a bed but never sleeps, a mouth but never eats?'", "a river", []string{"river"}},
+			{"Scratched on the bars: 'The more there is, the less you see. What is it?'", "darkness", []string{"the dark", "dark"}},
+		},
+		Escape:  "Claim Captain Redfeather's treasure and make it to the longboat before the ship goes down.",
+		Expired: "With a final groan, the Crimson Gull slips beneath the waves, taking you and her secrets to the bottom.",
+	},
+	"Time Machine Malfunction": {
+		Setting:   "A prototype time machine, stuck between moments in a flickering laboratory",
+		Backstory: "The chronal engine overloaded the moment you stepped inside. Now the lab cycles between past, present and future, and the doors only open when the timeline is stable.",
+		Rooms: []bankEntry{
+			{"Chronal Chamber", "The time machine's ring hums in the centre of the room, spitting sparks. Clocks on the wall all show different times."},
+			{"Victorian Workshop", "Gaslight flickers over brass gears and half-built automata. It smells of oil and coal smoke."},
+			{"Future Lab", "Holographic displays float in the air over gleaming white benches."},
+			{"Paradox Corridor", "The corridor loops back on itself. You can see the back of your own head in the distance."},
+			{"Control Room", "A bank of dials and levers controls the machine's temporal coordinates."},
+		},
+		Features: []string{"gear cabinet", "tesla lamp", "hologram projector", "flux capacitor", "workbench", "pocket watch display", "calendar wall", "cable bundle"},
+		Keys:     []string{"temporal key", "chrono-stabiliser", "brass gear key"},
+		Notes:    []string{"future newspaper", "inventor's blueprint", "timeline sketch", "note from yourself"},
+		Curios: []bankEntry{
+			{"dinosaur tooth", "A fossilised tooth that is somehow still warm."},
+			{"hover skate", "A single hover skate, wheels spinning in midair."},
+			{"sundial", "A tiny sundial whose shadow moves backwards."},
+			{"vinyl record", "A record labelled 'Greatest Hits of 2099'."},
+		},
+		Locks:   []string{"Temporal Coordinates Dial", "Paradox Lock", "Chronometer Keypad"},
+		Words:   []string{"paradox", "epoch", "century", "tomorrow"},
+		Symbols: []string{"dawn", "noon", "dusk", "midnight", "eclipse"},
+		Riddles: []bankRiddle{
+			{"Your own handwriting on the wall: 'What can you catch but not throw?'", "a cold", []string{"cold"}},
+			{"A hologram asks: 'What has hands but cannot clap?'", "a clock", []string{"clock", "watch"}},
+		},
+		Escape:  "Stabilise the timeline and step back into your own present.",
+		Expired: "The chronal engine shrieks and the world folds in on itself. You are scattered across a thousand Tuesdays.",
+	},
+	"Zombie Apocalypse Bunker": {
+		Setting:   "A Cold War bunker beneath a ruined city, surrounded by the undead",
+		Backstory: "You fled into the bunker as the horde closed in. The blast door sealed behind you - but the evacuation helicopter leaves from the roof, and the only way up is through the bunker's lockdown.",
+		Rooms: []bankEntry{
+			{"Blast Door Airlock", "Fists pound on the steel behind you. A red warning light turns lazily overhead."},
+			{"Mess Hall", "Tables are overturned and tinned food is scattered across the floor."},
+			{"Armoury", "Empty gun racks line the walls. Someone left in a hurry."},
+			{"Infirmary", "Bloodstained sheets cover the beds. One of them moves slightly."},
+			{"Radio Room", "A shortwave radio crackles with static and the occasional desperate voice."},
+		},
+		Features: []string{"footlocker", "supply shelf", "first aid kit", "weapon rack", "generator", "air vent", "bunk bed", "radio desk"},
+		Keys:     []string{"bunker keycard", "armoury key", "roof hatch key"},
+		Notes:    []string{"evacuation order", "survivor's journal", "scribbled map", "blood-smeared note"},
+		Curios: []bankEntry{
+			{"dog tags", "A set of dog tags belonging to a Sgt. Miller."},
+			{"canned beans", "A dented can of beans. Dinner, if you survive."},
+			{"broken flashlight", "A flashlight with a cracked lens and no batteries."},
+			{"teddy bear", "A grubby teddy bear missing an eye."},
+		},
+		Locks:   []string{"Lockdown Keypad", "Armoury Padlock", "Roof Hatch Control"},
+		Words:   []string{"survive", "rescue", "outbreak", "shelter"},
+		Symbols: []string{"red", "orange", "yellow", "green", "blue"},
+		Riddles: []bankRiddle{
+			{"Painted on the wall: 'What has many keys but can't open a single lock?'", "a piano", []string{"piano", "keyboard"}},
+			{"A note reads: 'I'm light as a feather, yet the strongest man can't hold me for long. What am I?'", "breath", []string{"your breath", "a breath"}},
+		},
+		Escape:  "Break the lockdown and reach the helicopter on the roof.",
+		Expired: "The blast door buckles. The last thing you hear is the helicopter leaving without you.",
+	},
+	"Magic Academy": {
+		Setting:   "The Arcanum, an ancient academy of magic perched on a floating island",
+		Backstory: "You snuck into the Arcanum after curfew to borrow a forbidden book. Now the wards have locked you in, and unless you escape before the headmistress returns, you'll be expelled - or worse.",
+		Rooms: []bankEntry{
+			{"Entrance Hall", "Enchanted suits of armour line the walls. Floating candles drift beneath a starry painted ceiling."},
+			{"Potions Classroom", "Cauldrons simmer unattended. Jars of eyes, roots and glowing powders fill the shelves."},
+			{"Forbidden Library", "Chained books rattle on the shelves. One of them growls."},
+			{"Astronomy Tower", "A great brass telescope points at the night sky through an open dome."},
+			{"Headmistress's Study", "A phoenix perch stands empty beside a desk covered in unopened letters."},
+		},
+		Features: []string{"cauldron", "enchanted bookshelf", "crystal ball", "wand cabinet", "suit of armour", "telescope", "portrait frame", "herb rack"},
+		Keys:     []string{"silver rune key", "owl feather quill", "headmistress's seal"},
+		Notes:    []string{"spellbook page", "detention slip", "star chart", "potion recipe"},
+		Curios: []bankEntry{
+			{"toad", "A warty toad that croaks a single word: 'Hurry.'"},
+			{"broken wand", "A wand snapped in two, still fizzing faintly."},
+			{"chocolate frog", "A chocolate frog that hops out of your hand."},
+			{"sorting stone", "A smooth stone that glows a different colour for everyone who holds it."},
+		},
+		Locks:   []string{"Rune Lock", "Warded Door", "Celestial Orrery"},
+		Words:   []string{"arcana", "wizard", "phoenix", "grimoire"},
+		Symbols: []string{"fire", "water", "earth", "air", "aether"},
+		Riddles: []bankRiddle{
+			{"A talking door asks: 'What has roots nobody sees, is taller than trees, up it goes, yet it never grows?'", "a mountain", []string{"mountain"}},
+			{"A portrait whispers: 'Speak my name and I am gone. What am I?'", "silence", nil},
+		},
+		Escape:  "Slip past the wards and make it back to the dormitory before dawn.",
+		Expired: "The doors swing open and the headmistress sweeps in, her expression thunderous. Your magical career is over.",
+	},
+	"Bank Heist Gone Wrong": {
+		Setting:   "The First Meridian Bank after hours, locked down by its security system",
+		Backstory: "The heist went wrong the moment your crew hit the alarm. They scattered, the building sealed itself, and the police are on their way. You need to crack the vault and get out before they arrive.",
+		Rooms: []bankEntry{
+			{"Lobby", "Marble floors, velvet ropes and a security camera blinking red. The revolving door is locked solid."},
+			{"Manager's Office", "A leather chair faces a wall of framed certificates. The desk is suspiciously tidy."},
+			{"Security Room", "A wall of monitors shows every corridor. Most of the screens show static."},
+			{"Safe Deposit Room", "Hundreds of small steel boxes line the walls from floor to ceiling."},
+			{"Main Vault", "A circular vault door the size of a car stands before you, its dial gleaming."},
+		},
+		Features: []string{"teller desk", "filing cabinet", "potted plant", "monitor bank", "deposit box", "shredder", "coat closet", "water cooler"},
+		Keys:     []string{"manager's keycard", "security badge", "deposit box key"},
+		Notes:    []string{"sticky note", "shredded memo", "guard rota", "crumpled receipt"},
+		Curios: []bankEntry{
+			{"gold bar", "A gold bar, far heavier than it looks."},
+			{"rubber stamp", "A rubber stamp reading 'APPROVED'."},
+			{"ski mask", "A ski mask dropped by one of your crew."},
+			{"fountain pen", "An expensive fountain pen with the bank's logo."},
+		},
+		Locks:   []string{"Vault Dial", "Security Keypad", "Time Lock Panel"},
+		Words:   []string{"jackpot", "getaway", "bullion", "ledger"},
+		Symbols: []string{"dollar", "euro", "pound", "yen", "franc"},
+		Riddles: []bankRiddle{
+			{"A note left by the manager: 'What has a neck but no head?'", "a bottle", []string{"bottle"}},
+			{"Engraved on the vault: 'The person who makes it has no need of it. What is it?'", "a coffin", []string{"coffin"}},
+		},
+		Escape:  "Crack the vault and slip out before the police arrive.",
+		Expired: "Sirens wail outside and blue lights flood the lobby. The heist is over.",
+	},
+	"Underwater Research Base": {
+		Setting:   "Abyssal research base Triton, three kilometres beneath the Pacific",
+		Backstory: "A quake cracked Triton's hull and sealed the bulkheads. The rest of the crew made it to the submersible; you didn't. The hull won't hold for long.",
+		Rooms: []bankEntry{
+			{"Moon Pool", "Black water laps at the edge of the open pool. Something large passes beneath the surface."},
+			{"Sonar Room", "A green sonar sweep pings steadily. Something is circling the base."},
+			{"Marine Biology Lab", "Tanks of bioluminescent creatures cast shifting blue light across the room."},
+			{"Pressure Control", "Gauges tremble in the red. A slow drip of seawater runs down one wall."},
+			{"Submersible Dock", "A small yellow submersible rests in its cradle, hatch sealed."},
+		},
+		Features: []string{"specimen tank", "diving locker", "sonar console", "pressure gauge", "bunk", "valve wheel", "equipment crate", "porthole"},
+		Keys:     []string{"pressure key", "dock access card", "valve handle"},
+		Notes:    []string{"research log", "dive table", "sonar printout", "evacuation checklist"},
+		Curios: []bankEntry{
+			{"glowing jellyfish", "A jellyfish in a jar, pulsing with soft blue light."},
+			{"conch shell", "A spiral conch. When you hold it to your ear, you hear groaning metal."},
+			{"diving watch", "A diving watch, its bezel set to an unknown countdown."},
+			{"anglerfish model", "A model of an anglerfish with an uncomfortably realistic grin."},
+		},
+		Locks:   []string{"Bulkhead Keypad", "Ballast Control", "Submersible Hatch"},
+		Words:   []string{"abyss", "pressure", "trench", "leviathan"},
+		Symbols: []string{"shark", "octopus", "whale", "squid", "turtle"},
+		Riddles: []bankRiddle{
+			{"Scratched into a bulkhead: 'What can fill a room but takes up no space?'", "light", []string{"the light"}},
+			{"On the sonar screen: 'What runs but never walks, has a mouth but never talks?'", "a river", []string{"river"}},
+		},
+		Escape:  "Restore pressure control and launch the submersible before the hull gives way.",
+		Expired: "The hull gives way with a deafening crack, and the ocean rushes in.",
+	},
+}
+
+// bankFor returns the word bank for a theme. Unknown themes are mapped onto one
+// of the built-in banks so the same theme always gets the same flavour.
+func bankFor(theme string) wordBank {
+	for name, bank := range wordBanks {
+		if strings.EqualFold(name, strings.TrimSpace(theme)) {
+			return bank
+		}
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(strings.TrimSpace(theme))))
+	return wordBanks[Themes[int(h.Sum32()%uint32(len(Themes)))]]
+}
@@ -0,0 +1,120 @@
+package game
+
+// WorldState holds everything a player's actions can change about the world.
+// The Scenario itself is never modified; item visibility and room locks are
+// tracked here as an overlay on top of the scenario definition.
+type WorldState struct {
+	CurrentRoom      string          `json:"current_room"`
+	Inventory        []string        `json:"inventory"`
+	SolvedPuzzles    []string        `json:"solved_puzzles"`
+	DiscoveredItems  []string        `json:"discovered_items"`
+	PerformedActions []string        `json:"performed_actions"`
+	DiscussedTopics  []string        `json:"discussed_topics"`
+	PuzzleProgress   map[string]int  `json:"puzzle_progress"`
+	ItemHidden       map[string]bool `json:"item_hidden"`
+	RoomLocked       map[string]bool `json:"room_locked"`
+}
+
+func newWorldState(scenario *Scenario) WorldState {
+	world := WorldState{
+		CurrentRoom:      scenario.Rooms[0].ID, // Start in first room
+		Inventory:        []string{},
+		SolvedPuzzles:    []string{},
+		DiscoveredItems:  []string{},
+		PerformedActions: []string{},
+		DiscussedTopics:  []string{},
+		PuzzleProgress:   make(map[string]int),
+		ItemHidden:       make(map[string]bool),
+		RoomLocked:       make(map[string]bool),
+	}
+
+	for _, item := range scenario.Items {
+		world.ItemHidden[item.ID] = item.Hidden
+	}
+	for _, room := range scenario.Rooms {
+		world.RoomLocked[room.ID] = room.Locked
+	}
+
+	return world
+}
+
+func (w WorldState) clone() WorldState {
+	clone := w
+	clone.Inventory = append([]string{}, w.Inventory...)
+	clone.SolvedPuzzles = append([]string{}, w.SolvedPuzzles...)
+	clone.DiscoveredItems = append([]string{}, w.DiscoveredItems...)
+	clone.PerformedActions = append([]string{}, w.PerformedActions...)
+	clone.DiscussedTopics = append([]string{}, w.DiscussedTopics...)
+	clone.PuzzleProgress = make(map[string]int, len(w.PuzzleProgress))
+	for id, step := range w.PuzzleProgress {
+		clone.PuzzleProgress[id] = step
+	}
+	clone.ItemHidden = make(map[string]bool, len(w.ItemHidden))
+	for id, hidden := range w.ItemHidden {
+		clone.ItemHidden[id] = hidden
+	}
+	clone.RoomLocked = make(map[string]bool, len(w.RoomLocked))
+	for id, locked := range w.RoomLocked {
+		clone.RoomLocked[id] = locked
+	}
+	return clone
+}
+
+// fillIn completes a world loaded from an older save. Saves from before the
+// world state kept whether items were hidden and rooms locked in the scenario
+// itself, so those are taken from there.
+func (w *WorldState) fillIn(scenario *Scenario) {
+	if w.PuzzleProgress == nil {
+		w.PuzzleProgress = make(map[string]int)
+	}
+	if w.ItemHidden == nil {
+		w.ItemHidden = make(map[string]bool)
+		for _, item := range scenario.Items {
+			w.ItemHidden[item.ID] = item.Hidden
+		}
+	}
+	if w.RoomLocked == nil {
+		w.RoomLocked = make(map[string]bool)
+		for _, room := range scenario.Rooms {
+			w.RoomLocked[room.ID] = room.Locked
+		}
+	}
+}
+
+func (w *WorldState) IsItemHidden(itemID string) bool {
+	return w.ItemHidden[itemID]
+}
+
+func (w *WorldState) IsRoomLocked(roomID string) bool {
+	return w.RoomLocked[roomID]
+}
+
+func (w *WorldState) setItemHidden(itemID string, hidden bool) {
+	if w.ItemHidden == nil {
+		w.ItemHidden = make(map[string]bool)
+	}
+	w.ItemHidden[itemID] = hidden
+}
+
+func (w *WorldState) setRoomLocked(roomID string, locked bool) {
+	if w.RoomLocked == nil {
+		w.RoomLocked = make(map[string]bool)
+	}
+	w.RoomLocked[roomID] = locked
+}
+
+func (s *GameState) clone() *GameState {
+	clone := *s
+	clone.WorldState = s.WorldState.clone()
+	clone.FailedAttempts = make(map[string]int, len(s.FailedAttempts))
+	for id, attempts := range s.FailedAttempts {
+		clone.FailedAttempts[id] = attempts
+	}
+	clone.TimerWarningsShown = append([]int{}, s.TimerWarningsShown...)
+	clone.HintsUnlocked = append([]int{}, s.HintsUnlocked...)
+	clone.HintsShown = append([]int{}, s.HintsShown...)
+	clone.RoomsVisited = append([]string{}, s.RoomsVisited...)
+	clone.SecretsFound = append([]string{}, s.SecretsFound...)
+	clone.Achievements = append([]string{}, s.Achievements...)
+	return &clone
+}
@@ -0,0 +1,90 @@
+package game
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+// lockedScenario adds state for the world overlay to track: a key hidden in
+// the spoon and a hall the door unlocks.
+func lockedScenario() *Scenario {
+	scenario := testScenario()
+	scenario.Rooms[1].Locked = true
+	scenario.Items = append(scenario.Items, Item{ID: "key", Name: "Key", Description: "A tiny key.", Hidden: true, RevealedBy: "search_spoon"})
+	scenario.Rooms[0].Items = append(scenario.Rooms[0].Items, "key")
+	scenario.Actions = []Action{
+		{ID: "search_spoon", Trigger: ActionTrigger{Type: "examine", Target: "spoon"}, Effects: []ActionEffect{{Type: "reveal_item", Target: "key"}}, Message: "Something glints in the spoon.", OneTimeOnly: true},
+		{ID: "open_hall", Trigger: ActionTrigger{Type: "solve", Target: "door"}, Effects: []ActionEffect{{Type: "unlock_room", Target: "hall"}}, Message: "The hall is open."},
+	}
+	return scenario
+}
+
+func TestEnginesShareScenario(t *testing.T) {
+	scenario := lockedScenario()
+	before, err := scenario.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	engines := make([]*Engine, 8)
+	for i := range engines {
+		engines[i] = NewEngine(scenario)
+		wg.Add(1)
+		go func(engine *Engine) {
+			defer wg.Done()
+			for _, command := range []string{"examine spoon", "take key", "solve open", "go hall", "solve swordfish"} {
+				if _, err := engine.ProcessCommand(command); err != nil {
+					t.Errorf("%q: %v", command, err)
+				}
+			}
+		}(engines[i])
+	}
+	wg.Wait()
+
+	for i, engine := range engines {
+		if !engine.IsGameWon() {
+			t.Errorf("engine %d didn't win", i)
+		}
+	}
+	after, err := scenario.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Error("playing changed the shared scenario")
+	}
+
+	// A new game of the same scenario starts from scratch
+	fresh := NewEngine(scenario)
+	if !fresh.GetState().IsItemHidden("key") || !fresh.GetState().IsRoomLocked("hall") {
+		t.Error("new engine sees another game's revealed key or unlocked hall")
+	}
+}
+
+func TestRestartWithoutReloading(t *testing.T) {
+	scenario := lockedScenario()
+	engine := NewEngine(scenario)
+	play(t, engine, "examine spoon", "take key", "solve open", "go hall")
+
+	if err := engine.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	state := engine.GetState()
+	if state.Scenario != scenario {
+		t.Error("restart replaced the scenario")
+	}
+	if state.CurrentRoom != "cell" || len(state.Inventory) != 0 || len(state.SolvedPuzzles) != 0 || state.Moves != 0 {
+		t.Errorf("after restart: in %s with %v, solved %v after %d moves; want a fresh game", state.CurrentRoom, state.Inventory, state.SolvedPuzzles, state.Moves)
+	}
+	if !state.IsItemHidden("key") || !state.IsRoomLocked("hall") {
+		t.Error("restart kept the revealed key or the unlocked hall")
+	}
+
+	// And the same commands win again
+	play(t, engine, "examine spoon", "take key", "solve open", "go hall", "solve swordfish")
+	if !engine.IsGameWon() {
+		t.Error("couldn't win after restarting")
+	}
+}
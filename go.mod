@@ -2,4 +2,8 @@ module github.com/tahcohcat/go-escape-ai
 
 go 1.21
 
-require github.com/sashabaranov/go-openai v1.20.4
+require (
+	github.com/BurntSushi/toml v1.3.2
+	github.com/sashabaranov/go-openai v1.20.4
+	gopkg.in/yaml.v3 v3.0.1
+)
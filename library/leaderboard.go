@@ -0,0 +1,159 @@
+package library
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tahcohcat/go-escape-ai/game"
+)
+
+const leaderboardDir = "leaderboards"
+
+// Run is one escape from a scenario.
+type Run struct {
+	Player      string        `json:"player"`
+	Time        time.Duration `json:"time"`
+	Moves       int           `json:"moves"`
+	HintsUsed   int           `json:"hints_used"`
+	Score       int           `json:"score"`
+	CompletedAt time.Time     `json:"completed_at"`
+}
+
+// Leaderboard holds every run of one scenario. It's keyed by a hash of the
+// scenario's content, so a scenario shared as a file, or generated again from
+// the same seed, shares its leaderboard whatever it's called.
+type Leaderboard struct {
+	Hash  string `json:"hash"`
+	Theme string `json:"theme"`
+	Runs  []Run  `json:"runs"`
+}
+
+// PersonalBest is a player's best in each category, which may come from different runs.
+type PersonalBest struct {
+	Player      string        `json:"player"`
+	BestTime    time.Duration `json:"best_time"`
+	FewestMoves int           `json:"fewest_moves"`
+	FewestHints int           `json:"fewest_hints"`
+	Runs        int           `json:"runs"`
+}
+
+func NewRun(player string, stats game.GameStats) Run {
+	player = strings.TrimSpace(player)
+	if player == "" {
+		player = "anonymous"
+	}
+	return Run{
+		Player:      player,
+		Time:        stats.Elapsed,
+		Moves:       stats.Moves,
+		HintsUsed:   stats.HintsUsed,
+		Score:       stats.Score,
+		CompletedAt: time.Now(),
+	}
+}
+
+// ScenarioHash identifies a scenario by its content.
+func ScenarioHash(scenario *game.Scenario) (string, error) {
+	data, err := scenario.ToJSON()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+func (l *Library) leaderboardPath(hash string) string {
+	return filepath.Join(l.Dir, leaderboardDir, hash+".json")
+}
+
+// Leaderboard returns the scenario's leaderboard, which is empty if nobody has escaped yet.
+func (l *Library) Leaderboard(scenario *game.Scenario) (*Leaderboard, error) {
+	hash, err := ScenarioHash(scenario)
+	if err != nil {
+		return nil, err
+	}
+
+	board := &Leaderboard{Hash: hash, Theme: scenario.Theme, Runs: []Run{}}
+	data, err := ioutil.ReadFile(l.leaderboardPath(hash))
+	if os.IsNotExist(err) {
+		return board, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, board); err != nil {
+		return nil, fmt.Errorf("failed to read leaderboard: %w", err)
+	}
+	return board, nil
+}
+
+// RecordRun adds a run to the scenario's leaderboard and returns the updated
+// leaderboard, along with whether the run beat the player's previous best time.
+func (l *Library) RecordRun(scenario *game.Scenario, run Run) (*Leaderboard, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	board, err := l.Leaderboard(scenario)
+	if err != nil {
+		return nil, false, err
+	}
+
+	previous, played := board.PersonalBest(run.Player)
+	board.Runs = append(board.Runs, run)
+
+	data, err := json.MarshalIndent(board, "", "  ")
+	if err != nil {
+		return nil, false, err
+	}
+	if err := os.MkdirAll(filepath.Join(l.Dir, leaderboardDir), 0755); err != nil {
+		return nil, false, err
+	}
+	if err := ioutil.WriteFile(l.leaderboardPath(board.Hash), data, 0644); err != nil {
+		return nil, false, fmt.Errorf("failed to save leaderboard: %w", err)
+	}
+	return board, played && run.Time < previous.BestTime, nil
+}
+
+// Ranked returns the runs fastest first, breaking ties by moves and then hints.
+func (b *Leaderboard) Ranked() []Run {
+	runs := append([]Run{}, b.Runs...)
+	sort.SliceStable(runs, func(i, j int) bool {
+		if runs[i].Time != runs[j].Time {
+			return runs[i].Time < runs[j].Time
+		}
+		if runs[i].Moves != runs[j].Moves {
+			return runs[i].Moves < runs[j].Moves
+		}
+		return runs[i].HintsUsed < runs[j].HintsUsed
+	})
+	return runs
+}
+
+// PersonalBest returns the player's bests, and false if they've never escaped.
+func (b *Leaderboard) PersonalBest(player string) (PersonalBest, bool) {
+	best := PersonalBest{Player: player}
+	for _, run := range b.Runs {
+		if !strings.EqualFold(run.Player, player) {
+			continue
+		}
+		if best.Runs == 0 || run.Time < best.BestTime {
+			best.BestTime = run.Time
+		}
+		if best.Runs == 0 || run.Moves < best.FewestMoves {
+			best.FewestMoves = run.Moves
+		}
+		if best.Runs == 0 || run.HintsUsed < best.FewestHints {
+			best.FewestHints = run.HintsUsed
+		}
+		best.Runs++
+	}
+	return best, best.Runs > 0
+}
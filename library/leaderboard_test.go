@@ -0,0 +1,87 @@
+package library
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRanked(t *testing.T) {
+	board := &Leaderboard{Runs: []Run{
+		{Player: "slow", Time: 3 * time.Minute, Moves: 10},
+		{Player: "more hints", Time: time.Minute, Moves: 20, HintsUsed: 2},
+		{Player: "more moves", Time: time.Minute, Moves: 25},
+		{Player: "fastest", Time: 30 * time.Second, Moves: 40},
+		{Player: "fewer hints", Time: time.Minute, Moves: 20, HintsUsed: 1},
+		{Player: "tied", Time: time.Minute, Moves: 20, HintsUsed: 2},
+	}}
+
+	want := []string{"fastest", "fewer hints", "more hints", "tied", "more moves", "slow"}
+	ranked := board.Ranked()
+	if len(ranked) != len(want) {
+		t.Fatalf("ranked %d runs, want %d", len(ranked), len(want))
+	}
+	for i, run := range ranked {
+		if run.Player != want[i] {
+			t.Errorf("rank %d = %s, want %s", i+1, run.Player, want[i])
+		}
+	}
+	if board.Runs[0].Player != "slow" {
+		t.Error("Ranked reordered the leaderboard's own runs")
+	}
+}
+
+func TestPersonalBest(t *testing.T) {
+	board := &Leaderboard{Runs: []Run{
+		{Player: "Ann", Time: 2 * time.Minute, Moves: 12, HintsUsed: 0},
+		{Player: "Ben", Time: 30 * time.Second, Moves: 5, HintsUsed: 0},
+		{Player: "ann", Time: time.Minute, Moves: 30, HintsUsed: 3},
+		{Player: "Ann", Time: 90 * time.Second, Moves: 20, HintsUsed: 1},
+	}}
+
+	best, ok := board.PersonalBest("ANN")
+	if !ok {
+		t.Fatal("Ann has escaped, but has no personal best")
+	}
+	want := PersonalBest{Player: "ANN", BestTime: time.Minute, FewestMoves: 12, FewestHints: 0, Runs: 3}
+	if best != want {
+		t.Errorf("Ann's best = %+v, want %+v", best, want)
+	}
+
+	if best, _ := board.PersonalBest("Ben"); best.Runs != 1 || best.BestTime != 30*time.Second {
+		t.Errorf("Ben's best = %+v, want the one run", best)
+	}
+	if _, ok := board.PersonalBest("Cat"); ok {
+		t.Error("Cat has a personal best without escaping")
+	}
+}
+
+func TestRecordRunReportsNewBest(t *testing.T) {
+	lib := openTestLibrary(t)
+	scenario := testScenario("Cell")
+
+	for _, step := range []struct {
+		run     Run
+		newBest bool
+	}{
+		{Run{Player: "Ann", Time: time.Minute}, false},
+		{Run{Player: "Ann", Time: 2 * time.Minute}, false},
+		{Run{Player: "Ann", Time: 45 * time.Second}, true},
+		{Run{Player: "Ben", Time: 10 * time.Second}, false},
+	} {
+		_, newBest, err := lib.RecordRun(scenario, step.run)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if newBest != step.newBest {
+			t.Errorf("%s in %v: new best = %v, want %v", step.run.Player, step.run.Time, newBest, step.newBest)
+		}
+	}
+
+	board, err := lib.Leaderboard(scenario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ranked := board.Ranked(); len(ranked) != 4 || ranked[0].Player != "Ben" {
+		t.Errorf("stored runs = %+v, want all four with Ben's first", ranked)
+	}
+}
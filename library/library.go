@@ -0,0 +1,394 @@
+package library
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tahcohcat/go-escape-ai/game"
+)
+
+const (
+	scenarioExt = ".json"
+	metadataExt = ".meta.json"
+)
+
+// Library stores scenarios in a directory, one file per scenario plus a
+// metadata file next to it. Scenario files may be JSON, YAML or TOML.
+type Library struct {
+	Dir string
+
+	mu sync.Mutex // serializes changes made by concurrent games
+}
+
+type Metadata struct {
+	Name       string        `json:"name"`
+	Theme      string        `json:"theme"`
+	CreatedAt  time.Time     `json:"created_at"`
+	Model      string        `json:"model,omitempty"` // what generated the scenario
+	Difficulty string        `json:"difficulty,omitempty"`
+	Completed  bool          `json:"completed"`
+	BestTime   time.Duration `json:"best_time,omitempty"`
+	Plays      int           `json:"plays"`
+}
+
+func (m Metadata) Created() string {
+	return m.CreatedAt.Format("2006-01-02")
+}
+
+func Open(dir string) (*Library, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create library: %w", err)
+	}
+	return &Library{Dir: dir}, nil
+}
+
+// scenarioPath returns the path of the named scenario in whichever format it
+// was stored, or the JSON path for a new one.
+func (l *Library) scenarioPath(name string) string {
+	for _, ext := range game.ScenarioExtensions {
+		path := filepath.Join(l.Dir, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(l.Dir, name+scenarioExt)
+}
+
+func (l *Library) metadataPath(name string) string {
+	return filepath.Join(l.Dir, name+metadataExt)
+}
+
+// List returns the metadata of every stored scenario, newest first.
+func (l *Library) List() ([]Metadata, error) {
+	files, err := ioutil.ReadDir(l.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read library: %w", err)
+	}
+
+	var entries []Metadata
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || strings.HasSuffix(name, metadataExt) {
+			continue
+		}
+		if _, err := game.FormatFromPath(name); err != nil {
+			continue
+		}
+
+		meta, err := l.Metadata(strings.TrimSuffix(name, filepath.Ext(name)))
+		if err != nil {
+			continue
+		}
+		entries = append(entries, *meta)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+	return entries, nil
+}
+
+func (l *Library) Exists(name string) bool {
+	if ValidateName(name) != nil {
+		return false
+	}
+	_, err := os.Stat(l.scenarioPath(name))
+	return err == nil
+}
+
+// Metadata returns a scenario's metadata. Scenarios copied into the library by
+// hand get metadata derived from the scenario itself.
+func (l *Library) Metadata(name string) (*Metadata, error) {
+	if !l.Exists(name) {
+		return nil, fmt.Errorf("scenario %s not found", name)
+	}
+
+	data, err := ioutil.ReadFile(l.metadataPath(name))
+	if err == nil {
+		var meta Metadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return nil, fmt.Errorf("failed to read metadata for %s: %w", name, err)
+		}
+		meta.Name = name
+		return &meta, nil
+	}
+
+	scenario, err := l.Load(name)
+	if err != nil {
+		return nil, err
+	}
+	meta := Metadata{Name: name, Theme: scenario.Theme, Difficulty: Difficulty(scenario)}
+	if info, err := os.Stat(l.scenarioPath(name)); err == nil {
+		meta.CreatedAt = info.ModTime()
+	}
+	return &meta, nil
+}
+
+func (l *Library) Load(name string) (*game.Scenario, error) {
+	if !l.Exists(name) {
+		return nil, fmt.Errorf("scenario %s not found", name)
+	}
+	return game.LoadScenarioFile(l.scenarioPath(name))
+}
+
+// Add stores a new scenario under a name derived from its theme and returns
+// that name.
+func (l *Library) Add(scenario *game.Scenario, model string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	name := l.uniqueName(Slug(scenario.Theme))
+
+	data, err := scenario.ToJSON()
+	if err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(l.scenarioPath(name), data, 0644); err != nil {
+		return "", fmt.Errorf("failed to save scenario: %w", err)
+	}
+
+	meta := &Metadata{
+		Name:       name,
+		Theme:      scenario.Theme,
+		CreatedAt:  time.Now(),
+		Model:      model,
+		Difficulty: Difficulty(scenario),
+	}
+	return name, l.saveMetadata(meta)
+}
+
+// Import copies a scenario file into the library as-is, keeping its format and
+// any comments, and returns its name.
+func (l *Library) Import(path string) (string, error) {
+	scenario, err := game.LoadScenarioFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to load %s: %w", path, err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	name := l.uniqueName(Slug(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))))
+	if err := ioutil.WriteFile(filepath.Join(l.Dir, name+strings.ToLower(filepath.Ext(path))), data, 0644); err != nil {
+		return "", fmt.Errorf("failed to save scenario: %w", err)
+	}
+
+	meta := &Metadata{
+		Name:       name,
+		Theme:      scenario.Theme,
+		CreatedAt:  time.Now(),
+		Model:      "imported",
+		Difficulty: Difficulty(scenario),
+	}
+	return name, l.saveMetadata(meta)
+}
+
+// Save writes an edited scenario back in the format it was stored in.
+func (l *Library) Save(name string, scenario *game.Scenario) error {
+	meta, err := l.Metadata(name)
+	if err != nil {
+		return err
+	}
+	if err := game.SaveScenarioFile(scenario, l.scenarioPath(name)); err != nil {
+		return fmt.Errorf("failed to save scenario: %w", err)
+	}
+
+	meta.Theme = scenario.Theme
+	meta.Difficulty = Difficulty(scenario)
+	return l.saveMetadata(meta)
+}
+
+func (l *Library) Delete(name string) error {
+	if !l.Exists(name) {
+		return fmt.Errorf("scenario %s not found", name)
+	}
+	if err := os.Remove(l.scenarioPath(name)); err != nil {
+		return err
+	}
+	os.Remove(l.metadataPath(name))
+	return nil
+}
+
+func (l *Library) Rename(oldName, newName string) error {
+	if err := ValidateName(newName); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.Exists(oldName) {
+		return fmt.Errorf("scenario %s not found", oldName)
+	}
+	if l.Exists(newName) {
+		return fmt.Errorf("scenario %s already exists", newName)
+	}
+
+	oldPath := l.scenarioPath(oldName)
+	if err := os.Rename(oldPath, filepath.Join(l.Dir, newName+filepath.Ext(oldPath))); err != nil {
+		return err
+	}
+	if _, err := os.Stat(l.metadataPath(oldName)); err == nil {
+		return os.Rename(l.metadataPath(oldName), l.metadataPath(newName))
+	}
+	return nil
+}
+
+// Export writes the scenario so it can be shared or edited, in the format
+// given by the file's extension.
+func (l *Library) Export(name, path string) error {
+	scenario, err := l.Load(name)
+	if err != nil {
+		return err
+	}
+	return game.SaveScenarioFile(scenario, path)
+}
+
+// Find returns the name of the stored scenario with the same content.
+func (l *Library) Find(scenario *game.Scenario) (string, bool) {
+	data, err := scenario.ToJSON()
+	if err != nil {
+		return "", false
+	}
+
+	entries, err := l.List()
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		stored, err := l.Load(entry.Name)
+		if err != nil {
+			continue
+		}
+		if storedData, err := stored.ToJSON(); err == nil && bytes.Equal(data, storedData) {
+			return entry.Name, true
+		}
+	}
+	return "", false
+}
+
+func (l *Library) RecordPlay(name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	meta, err := l.Metadata(name)
+	if err != nil {
+		return err
+	}
+	meta.Plays++
+	return l.saveMetadata(meta)
+}
+
+// RecordCompletion marks the scenario completed and keeps the best time.
+func (l *Library) RecordCompletion(name string, elapsed time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	meta, err := l.Metadata(name)
+	if err != nil {
+		return err
+	}
+	meta.Completed = true
+	if meta.BestTime == 0 || elapsed < meta.BestTime {
+		meta.BestTime = elapsed
+	}
+	return l.saveMetadata(meta)
+}
+
+func (l *Library) saveMetadata(meta *Metadata) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(l.metadataPath(meta.Name), data, 0644)
+}
+
+func (l *Library) uniqueName(base string) string {
+	name := base
+	for n := 2; l.Exists(name); n++ {
+		name = fmt.Sprintf("%s-%d", base, n)
+	}
+	return name
+}
+
+// Slug turns a theme into a library name, e.g. "Pirate Ship" becomes "pirate-ship".
+func Slug(theme string) string {
+	var slug strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(theme) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			if dash && slug.Len() > 0 {
+				slug.WriteRune('-')
+			}
+			slug.WriteRune(r)
+			dash = false
+		default:
+			dash = true
+		}
+	}
+
+	if slug.Len() == 0 {
+		return "scenario"
+	}
+	return slug.String()
+}
+
+func ValidateName(name string) error {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid scenario name %q", name)
+	}
+	if strings.HasSuffix(name, strings.TrimSuffix(metadataExt, scenarioExt)) {
+		return fmt.Errorf("invalid scenario name %q", name)
+	}
+	return nil
+}
+
+// Difficulty returns the level the scenario was generated for, or an estimate
+// for scenarios that don't say.
+func Difficulty(scenario *game.Scenario) string {
+	if scenario.Difficulty != "" {
+		return scenario.Difficulty
+	}
+	return EstimateDifficulty(scenario)
+}
+
+// EstimateDifficulty gives a rough rating from the scenario's size and time limit.
+func EstimateDifficulty(scenario *game.Scenario) string {
+	score := len(scenario.Puzzles) + len(scenario.Rooms)/2
+	if scenario.Timer != nil {
+		score++
+	}
+	for _, puzzle := range scenario.Puzzles {
+		if puzzle.Type == game.PuzzleTypeCipher || puzzle.Type == game.PuzzleTypeSequence {
+			score++
+		}
+	}
+
+	switch {
+	case score <= 4:
+		return game.DifficultyEasy
+	case score <= 7:
+		return game.DifficultyNormal
+	default:
+		return game.DifficultyHard
+	}
+}
+
+// FormatDuration renders a duration as m:ss.
+func FormatDuration(d time.Duration) string {
+	seconds := int(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
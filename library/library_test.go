@@ -0,0 +1,208 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/tahcohcat/go-escape-ai/game"
+)
+
+// testScenario is a one-room escape with the given theme.
+func testScenario(theme string) *game.Scenario {
+	return &game.Scenario{
+		SchemaVersion: game.SchemaVersion,
+		Theme:         theme,
+		Rooms:         []game.Room{{ID: "cell", Name: "Cell", Description: "A bare cell.", Puzzles: []string{"door"}}},
+		Puzzles:       []game.Puzzle{{ID: "door", Name: "Door", Description: "The door asks a riddle.", Solution: "open"}},
+	}
+}
+
+func openTestLibrary(t *testing.T) *Library {
+	t.Helper()
+	lib, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lib
+}
+
+func TestSlug(t *testing.T) {
+	tests := map[string]string{
+		"Pirate Ship":          "pirate-ship",
+		"  The  Lost--Temple ": "the-lost-temple",
+		"Room 101!":            "room-101",
+		"Café Noir":            "caf-noir",
+		"???":                  "scenario",
+		"":                     "scenario",
+	}
+	for theme, want := range tests {
+		if got := Slug(theme); got != want {
+			t.Errorf("Slug(%q) = %q, want %q", theme, got, want)
+		}
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"pirate-ship", true},
+		{"pirate ship 2", true},
+		{"", false},
+		{"../escape", false},
+		{"dir/escape", false},
+		{".hidden", false},
+		{"escape.meta", false},
+	}
+	for _, test := range tests {
+		if err := ValidateName(test.name); (err == nil) != test.valid {
+			t.Errorf("ValidateName(%q) = %v, want valid %v", test.name, err, test.valid)
+		}
+	}
+}
+
+func TestUniqueName(t *testing.T) {
+	lib := openTestLibrary(t)
+	if name := lib.uniqueName("cell"); name != "cell" {
+		t.Errorf("uniqueName in an empty library = %q, want cell", name)
+	}
+
+	for _, want := range []string{"cell", "cell-2", "cell-3"} {
+		name, err := lib.Add(testScenario("Cell"), "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != want {
+			t.Errorf("Add = %q, want %q", name, want)
+		}
+	}
+}
+
+func TestRename(t *testing.T) {
+	lib := openTestLibrary(t)
+	name, _ := lib.Add(testScenario("Cell"), "test")
+	other, _ := lib.Add(testScenario("Attic"), "test")
+
+	if err := lib.Rename(name, "dungeon"); err != nil {
+		t.Fatal(err)
+	}
+	if lib.Exists(name) || !lib.Exists("dungeon") {
+		t.Error("the scenario wasn't moved to its new name")
+	}
+	meta, err := lib.Metadata("dungeon")
+	if err != nil || meta.Name != "dungeon" || meta.Model != "test" {
+		t.Errorf("metadata = %+v, %v, want the original metadata under the new name", meta, err)
+	}
+	if _, err := os.Stat(lib.metadataPath(name)); !os.IsNotExist(err) {
+		t.Error("the old metadata file was left behind")
+	}
+
+	if err := lib.Rename("dungeon", other); err == nil {
+		t.Error("renamed over an existing scenario")
+	}
+	if err := lib.Rename("dungeon", "../dungeon"); err == nil {
+		t.Error("renamed to an invalid name")
+	}
+	if err := lib.Rename("missing", "found"); err == nil {
+		t.Error("renamed a scenario that doesn't exist")
+	}
+}
+
+func TestDelete(t *testing.T) {
+	lib := openTestLibrary(t)
+	name, _ := lib.Add(testScenario("Cell"), "test")
+
+	if err := lib.Delete(name); err != nil {
+		t.Fatal(err)
+	}
+	if lib.Exists(name) {
+		t.Error("the scenario is still there")
+	}
+	if _, err := os.Stat(lib.metadataPath(name)); !os.IsNotExist(err) {
+		t.Error("the metadata file was left behind")
+	}
+	if err := lib.Delete(name); err == nil {
+		t.Error("deleted a scenario twice")
+	}
+}
+
+func TestExportAndImport(t *testing.T) {
+	lib := openTestLibrary(t)
+	name, _ := lib.Add(testScenario("Cell"), "test")
+
+	path := filepath.Join(t.TempDir(), "Night Shift.yaml")
+	if err := lib.Export(name, path); err != nil {
+		t.Fatal(err)
+	}
+	exported, err := game.LoadScenarioFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exported.Theme != "Cell" || len(exported.Puzzles) != 1 {
+		t.Errorf("exported scenario = %+v, want the stored one", exported)
+	}
+
+	imported, err := lib.Import(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported != "night-shift" {
+		t.Errorf("imported as %q, want a name from the file name", imported)
+	}
+	if _, err := os.Stat(filepath.Join(lib.Dir, "night-shift.yaml")); err != nil {
+		t.Errorf("the import didn't keep its YAML format: %v", err)
+	}
+	meta, err := lib.Metadata(imported)
+	if err != nil || meta.Model != "imported" || meta.Theme != "Cell" {
+		t.Errorf("metadata = %+v, %v, want an imported Cell", meta, err)
+	}
+	if found, ok := lib.Find(exported); !ok || (found != name && found != imported) {
+		t.Errorf("Find = %q, %v, want the stored scenario", found, ok)
+	}
+
+	if _, err := lib.Import(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("imported a file that doesn't exist")
+	}
+}
+
+func TestConcurrentImportsGetTheirOwnNames(t *testing.T) {
+	lib := openTestLibrary(t)
+	path := filepath.Join(t.TempDir(), "cell.json")
+	if err := game.SaveScenarioFile(testScenario("Cell"), path); err != nil {
+		t.Fatal(err)
+	}
+
+	const imports = 8
+	names := make(chan string, imports)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < imports; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			name, err := lib.Import(path)
+			if err != nil {
+				t.Error(err)
+			}
+			names <- name
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(names)
+
+	seen := make(map[string]bool)
+	for name := range names {
+		if seen[name] {
+			t.Errorf("two imports were both named %q", name)
+		}
+		seen[name] = true
+	}
+	if entries, _ := lib.List(); len(entries) != imports {
+		t.Errorf("library has %d scenarios, want %d", len(entries), imports)
+	}
+}
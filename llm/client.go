@@ -3,13 +3,23 @@ package llm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/sashabaranov/go-openai"
 	"github.com/tahcohcat/go-escape-ai/game"
 )
 
+// model is used for everything: scenarios, narration and characters.
+const model = openai.GPT3Dot5Turbo
+
+// generationAttempts is how many times the model gets to fix a scenario that
+// fails validation before generation gives up.
+const generationAttempts = 3
+
 type Client struct {
 	client *openai.Client
 }
@@ -26,7 +36,12 @@ func NewClient() *Client {
 	}
 }
 
-func (c *Client) GenerateScenario(theme string) (*game.Scenario, error) {
+// Model returns the name of the model used to generate scenarios.
+func (c *Client) Model() string {
+	return model
+}
+
+func (c *Client) GenerateScenario(theme string, level game.DifficultyLevel) (*game.Scenario, error) {
 	if c == nil || c.client == nil {
 		return nil, fmt.Errorf("LLM client not initialized")
 	}
@@ -37,11 +52,12 @@ Create a JSON response with the following structure:
 - theme: The main theme
 - setting: Where the escape room takes place
 - backstory: Brief backstory explaining why the player is trapped
-- rooms: Array of 3-5 interconnected rooms with:
+- rooms: Array of %d-%d interconnected rooms with:
   - id, name, description
   - items (array of item IDs found in this room)
   - puzzles (array of puzzle IDs in this room)
   - exits (array of room IDs this room connects to)
+  - npcs (optional array of NPC IDs present in this room)
   - locked (boolean), unlock_key (item ID needed to unlock)
 - items: Array of interactive objects with:
   - id, name, description
@@ -51,41 +67,191 @@ Create a JSON response with the following structure:
   - id, name, description, solution
   - required_items (array of item IDs needed)
   - reward (what happens when solved)
+  - type (optional): one of
+    - "answer" (default): the player types the solution
+    - "sequence": mechanical puzzles worked through ordered steps (levers, dials, clock hands)
+    - "combination": numeric combination lock, solution like "4-7-1", opened with "enter 4-7-1 on lock"
+    - "keypad": keypad code of digits, letters, * or #, e.g. "3141#"
+    - "cipher": solution is the plaintext; include cipher {method: "caesar" with shift, or "substitution" with a 26-letter key}; the game shows the encrypted text
+    - "riddle": solution plus optional answers (array of other accepted answers)
+    - "ordering": solution is a comma-separated order of things to arrange, e.g. "red, green, blue"
+  - steps (for sequence puzzles): ordered array of {action, feedback} where action is the exact command the player types, e.g. "pull red lever"
+  - reset_on_mistake (boolean), mistake_message (for sequence puzzles)
+- npcs (optional): Characters the player can talk to, with:
+  - id, name, description, greeting, default_response
+  - topics: dialogue tree nodes with id, keywords, response, optional conditions and effects
+    (conditions use types "has_item", "in_room", "puzzle_solved", "action_performed", "topic_discussed" with value "npc_id.topic_id";
+     effects use types "reveal_item", "hide_item", "unlock_room", "add_inventory", "remove_inventory")
+  - trades: items the NPC accepts, with wants (item ID), gives (item ID), response
+  - persona (optional): personality for AI-voiced replies
+  - knowledge (optional): facts the NPC may share, each with id, fact and optional conditions gating when it may be revealed
 - win_condition: How the player escapes
 - hints: Object mapping room IDs to helpful hints
+- timer (optional): A countdown for timed escapes with:
+  - mode ("real_time" or "turns"), limit (seconds or moves)
+  - warnings (array of {remaining, message} fired as time runs low)
+  - expired_message (what happens when time runs out)
+- fail_conditions (optional): Ways to lose, each with:
+  - id, type ("timer_expired", "failed_attempts", "action_triggered")
+  - target (puzzle ID for failed_attempts, action ID for a trap action), threshold (wrong answers allowed)
+  - message (the ending shown when the player fails)
+- scoring (optional): secrets (array of IDs of optional items worth finding, not needed to escape); leave out the point values to use the defaults
+- achievements (optional): Goals worth bragging about, each with:
+  - id, name, description, points
+  - conditions (same types as NPC topic conditions), on_escape (only earned by escaping), max_hints, max_moves (limits checked on escaping)
+
+Make it %s difficulty but solvable. Include at least %d puzzles and %d items, with at least %d of the items hidden initially.`,
+		theme, level.MinRooms, level.MaxRooms, level.Name, level.Puzzles, level.Items, level.HiddenItems)
+
+	schema, err := game.ScenarioSchema()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build scenario schema: %w", err)
+	}
+	prompt += fmt.Sprintf("\n\nThe JSON must conform to this JSON Schema:\n%s\n\nLeave out schema_version.", schema)
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: "You are a creative escape room designer. Generate detailed, immersive scenarios with logical puzzles and interconnected elements. Always respond with valid JSON only.",
+		},
+		{
+			Role:    openai.ChatMessageRoleUser,
+			Content: prompt,
+		},
+	}
+
+	// JSON mode doesn't hold the model to the schema, so every scenario is
+	// checked, and the model is shown what's wrong with it until it gets it right
+	var problems []string
+	for attempt := 0; attempt < generationAttempts; attempt++ {
+		resp, err := c.client.CreateChatCompletion(
+			context.Background(),
+			openai.ChatCompletionRequest{
+				Model:       model,
+				Messages:    messages,
+				Temperature: 0.8,
+				ResponseFormat: &openai.ChatCompletionResponseFormat{
+					Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+				},
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate scenario: %w", err)
+		}
+		if len(resp.Choices) == 0 {
+			return nil, fmt.Errorf("failed to generate scenario: no response")
+		}
+
+		content := resp.Choices[0].Message.Content
+		var scenario *game.Scenario
+		scenario, problems = checkScenario([]byte(content))
+		if len(problems) == 0 {
+			return scenario, nil
+		}
+
+		messages = append(messages,
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: content},
+			openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf("That scenario has these problems:\n- %s\n\nFix them and respond with the whole corrected scenario as JSON.", strings.Join(problems, "\n- ")),
+			},
+		)
+	}
+
+	return nil, fmt.Errorf("generated scenario is still invalid after %d attempts: %s", generationAttempts, strings.Join(problems, "; "))
+}
+
+// checkScenario parses a generated scenario, returning what's wrong with it if
+// it doesn't match the schema, is from an unknown schema version, or fails
+// validation.
+func checkScenario(data []byte) (*game.Scenario, []string) {
+	problems, err := game.CheckSchema(data)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("the response isn't valid JSON: %v", err)}
+	}
+	if len(problems) > 0 {
+		return nil, problems
+	}
 
-Make it challenging but solvable. Include at least 3 puzzles and 5 items. Some items should be hidden initially.`, theme)
+	var scenario game.Scenario
+	if err := json.Unmarshal(data, &scenario); err != nil {
+		return nil, []string{fmt.Sprintf("the scenario couldn't be read: %v", err)}
+	}
+	// Scenarios without a version get their actions and progressive hints
+	// filled in from revealed_by and the room hints
+	if err := game.MigrateScenario(&scenario); err != nil {
+		return nil, []string{err.Error()}
+	}
+	if problems := scenario.Validate(); len(problems) > 0 {
+		return nil, problems
+	}
+	return &scenario, nil
+}
+
+func (c *Client) VoiceNPC(req game.DialogueRequest) (string, error) {
+	if c == nil || c.client == nil {
+		return "", fmt.Errorf("LLM client not initialized")
+	}
+
+	facts := "(nothing relevant)"
+	if len(req.Facts) > 0 {
+		facts = "- " + strings.Join(req.Facts, "\n- ")
+	}
+
+	authored := ""
+	if req.AuthoredLine != "" {
+		authored = fmt.Sprintf("\n\nThe scripted answer to this question is: %s\nStay consistent with it.", req.AuthoredLine)
+	}
+
+	prompt := fmt.Sprintf(`You are voicing %s, a character in an escape room game.
+
+Theme: %s
+Setting: %s
+Character: %s
+Persona: %s
+
+Everything the character knows and may share:
+%s%s
+
+The player asks about: %s
+
+Reply in character in 1-3 sentences. Only use the facts listed above. If the facts don't cover the question, stay in character and deflect. Never invent puzzle solutions, codes, or answers.`,
+		req.NPC.Name,
+		req.Scenario.Theme,
+		req.Scenario.Setting,
+		req.NPC.Description,
+		req.NPC.Persona,
+		facts,
+		authored,
+		req.Question)
 
 	resp, err := c.client.CreateChatCompletion(
 		context.Background(),
 		openai.ChatCompletionRequest{
-			Model: openai.GPT3Dot5Turbo,
+			Model: model,
 			Messages: []openai.ChatCompletionMessage{
 				{
 					Role:    openai.ChatMessageRoleSystem,
-					Content: "You are a creative escape room designer. Generate detailed, immersive scenarios with logical puzzles and interconnected elements. Always respond with valid JSON only.",
+					Content: "You voice non-player characters in an escape room game. You only know the facts you are given and never reveal anything else.",
 				},
 				{
 					Role:    openai.ChatMessageRoleUser,
 					Content: prompt,
 				},
 			},
-			Temperature: 0.8,
+			Temperature: 0.7,
+			MaxTokens:   150,
 		},
 	)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate scenario: %w", err)
+		return "", fmt.Errorf("failed to voice NPC: %w", err)
 	}
-
-	var scenario game.Scenario
-	content := resp.Choices[0].Message.Content
-	
-	if err := json.Unmarshal([]byte(content), &scenario); err != nil {
-		return nil, fmt.Errorf("failed to parse generated scenario: %w", err)
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("failed to voice NPC: no response")
 	}
 
-	return &scenario, nil
+	return resp.Choices[0].Message.Content, nil
 }
 
 type NarrationContext struct {
@@ -98,12 +264,7 @@ type NarrationContext struct {
 	ProgressiveHints []string
 }
 
-func (c *Client) GenerateNarration(ctx NarrationContext) (string, error) {
-	if c == nil || c.client == nil {
-		// Fallback to basic narration if LLM unavailable
-		return c.fallbackNarration(ctx), nil
-	}
-
+func narrationRequest(ctx NarrationContext) openai.ChatCompletionRequest {
 	scenario := ctx.GameState.Scenario
 	
 	hintsContext := ""
@@ -115,7 +276,7 @@ func (c *Client) GenerateNarration(ctx NarrationContext) (string, error) {
 	room, _ := ctx.GameState.Scenario.GetRoom(ctx.GameState.CurrentRoom)
 	if room != nil {
 		for _, itemID := range room.Items {
-			if item, err := ctx.GameState.Scenario.GetItem(itemID); err == nil && !item.Hidden {
+			if item, err := ctx.GameState.Scenario.GetItem(itemID); err == nil && !ctx.GameState.IsItemHidden(itemID) {
 				visibleItems = append(visibleItems, item.Name)
 			}
 		}
@@ -152,32 +313,75 @@ Keep it brief, atmospheric, and avoid stating facts the player already knows.`,
 		ctx.GameState.CommandAttempts,
 		hintsContext)
 
-	resp, err := c.client.CreateChatCompletion(
-		context.Background(),
-		openai.ChatCompletionRequest{
-			Model: openai.GPT3Dot5Turbo,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: "You are a mysterious, slightly ominous AI narrator for an escape room. Be atmospheric and engaging, but don't give away solutions directly.",
-				},
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: prompt,
-				},
+	return openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You are a mysterious, slightly ominous AI narrator for an escape room. Be atmospheric and engaging, but don't give away solutions directly.",
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompt,
 			},
-			Temperature: 0.7,
-			MaxTokens:   150,
 		},
-	)
+		Temperature: 0.7,
+		MaxTokens:   150,
+	}
+}
 
-	if err != nil {
+func (c *Client) GenerateNarration(ctx NarrationContext) (string, error) {
+	if c == nil || c.client == nil {
+		// Fallback to basic narration if LLM unavailable
+		return c.fallbackNarration(ctx), nil
+	}
+
+	resp, err := c.client.CreateChatCompletion(context.Background(), narrationRequest(ctx))
+	if err != nil || len(resp.Choices) == 0 {
 		return c.fallbackNarration(ctx), nil
 	}
 
 	return resp.Choices[0].Message.Content, nil
 }
 
+// StreamNarration generates narration like GenerateNarration, but calls onToken
+// with each piece of text as it arrives. It stops early, returning the context's
+// error, if ctx is cancelled.
+func (c *Client) StreamNarration(ctx context.Context, narration NarrationContext, onToken func(string)) error {
+	if c == nil || c.client == nil {
+		onToken(c.fallbackNarration(narration))
+		return nil
+	}
+
+	req := narrationRequest(narration)
+	req.Stream = true
+	stream, err := c.client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		onToken(c.fallbackNarration(narration))
+		return nil
+	}
+	defer stream.Close()
+
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if len(resp.Choices) > 0 && resp.Choices[0].Delta.Content != "" {
+			onToken(resp.Choices[0].Delta.Content)
+		}
+	}
+}
+
 func (c *Client) fallbackNarration(ctx NarrationContext) string {
 	responses := []string{
 		fmt.Sprintf("%s The air feels thick with mystery.", ctx.LastResult),
@@ -0,0 +1,136 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/tahcohcat/go-escape-ai/game"
+)
+
+func generatedJSON(t *testing.T, change func(s *game.Scenario)) string {
+	t.Helper()
+	level, _ := game.Difficulty(game.DifficultyNormal)
+	scenario := game.GenerateScenario("Haunted Manor", 5, level)
+	if change != nil {
+		change(scenario)
+	}
+	data, err := scenario.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCheckScenario(t *testing.T) {
+	if _, problems := checkScenario([]byte(generatedJSON(t, nil))); len(problems) > 0 {
+		t.Fatalf("valid scenario rejected: %v", problems)
+	}
+
+	tests := map[string]string{
+		"not JSON":       "Here is your scenario!",
+		"schema":         strings.Replace(generatedJSON(t, nil), `"theme"`, `"title"`, 1),
+		"newer version":  generatedJSON(t, func(s *game.Scenario) { s.SchemaVersion = game.SchemaVersion + 1 }),
+		"broken exit":    generatedJSON(t, func(s *game.Scenario) { s.Rooms[0].Exits = append(s.Rooms[0].Exits, "nowhere") }),
+		"missing puzzle": generatedJSON(t, func(s *game.Scenario) { s.Puzzles = nil }),
+	}
+	for name, content := range tests {
+		if scenario, problems := checkScenario([]byte(content)); scenario != nil || len(problems) == 0 {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func TestGenerateScenarioRetriesInvalidScenarios(t *testing.T) {
+	replies := []string{
+		generatedJSON(t, func(s *game.Scenario) { s.Rooms[0].Exits = append(s.Rooms[0].Exits, "nowhere") }),
+		generatedJSON(t, nil),
+	}
+	var requests []openai.ChatCompletionRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{
+				Role:    openai.ChatMessageRoleAssistant,
+				Content: replies[len(requests)-1],
+			}}},
+		})
+	}))
+	defer server.Close()
+
+	config := openai.DefaultConfig("test")
+	config.BaseURL = server.URL + "/v1"
+	client := &Client{client: openai.NewClientWithConfig(config)}
+
+	level, _ := game.Difficulty(game.DifficultyNormal)
+	scenario, err := client.GenerateScenario("Haunted Manor", level)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems := scenario.Validate(); len(problems) > 0 {
+		t.Errorf("returned an invalid scenario: %v", problems)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("made %d requests, want 2", len(requests))
+	}
+	if feedback := requests[1].Messages[len(requests[1].Messages)-1].Content; !strings.Contains(feedback, "nowhere") {
+		t.Errorf("the retry didn't say what was wrong: %q", feedback)
+	}
+	if requests[0].Model != client.Model() {
+		t.Errorf("generated with %s, but Model() says %s", requests[0].Model, client.Model())
+	}
+}
+
+func TestGenerateScenarioGivesUp(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: "{}"}}},
+		})
+	}))
+	defer server.Close()
+
+	config := openai.DefaultConfig("test")
+	config.BaseURL = server.URL + "/v1"
+	client := &Client{client: openai.NewClientWithConfig(config)}
+
+	level, _ := game.Difficulty(game.DifficultyNormal)
+	if _, err := client.GenerateScenario("Haunted Manor", level); err == nil {
+		t.Error("expected an error")
+	}
+	if attempts != generationAttempts {
+		t.Errorf("made %d attempts, want %d", attempts, generationAttempts)
+	}
+}
+
+func TestEmptyResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{}})
+	}))
+	defer server.Close()
+
+	config := openai.DefaultConfig("test")
+	config.BaseURL = server.URL + "/v1"
+	client := &Client{client: openai.NewClientWithConfig(config)}
+
+	level, _ := game.Difficulty(game.DifficultyNormal)
+	scenario := game.GenerateScenario("Haunted Manor", 5, level)
+	npc := &game.NPC{ID: "butler", Name: "Butler", Persona: "Stiff and formal."}
+	if reply, err := client.VoiceNPC(game.DialogueRequest{Scenario: scenario, NPC: npc, Question: "the key"}); err == nil {
+		t.Errorf("VoiceNPC with no choices = %q, want an error", reply)
+	}
+
+	state := game.NewEngine(scenario).GetState()
+	room, _ := scenario.GetRoom(state.CurrentRoom)
+	narration, err := client.GenerateNarration(NarrationContext{CurrentRoom: room, LastResult: "The door creaks.", GameState: state})
+	if err != nil || !strings.HasPrefix(narration, "The door creaks.") {
+		t.Errorf("GenerateNarration with no choices = %q, %v, want the fallback narration", narration, err)
+	}
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/tahcohcat/go-escape-ai/game"
+)
+
+// hostedRace is a race and the sessions its racers play in.
+type hostedRace struct {
+	id      string
+	name    string // library name, empty if the scenario isn't in the library
+	race    *game.Race
+	created time.Time
+}
+
+type raceView struct {
+	ID        string         `json:"id"`
+	Scenario  string         `json:"scenario,omitempty"` // library name
+	Theme     string         `json:"theme"`
+	Started   bool           `json:"started"`
+	StartedAt *time.Time     `json:"started_at,omitempty"`
+	Finished  bool           `json:"finished"`
+	Standings []standingView `json:"standings"`
+}
+
+type standingView struct {
+	game.Standing
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+}
+
+type joinRequest struct {
+	Name string `json:"name"`
+}
+
+func (hosted *hostedRace) view() raceView {
+	view := raceView{
+		ID:        hosted.id,
+		Scenario:  hosted.name,
+		Theme:     hosted.race.Scenario().Theme,
+		Finished:  hosted.race.Finished(),
+		Standings: []standingView{},
+	}
+	if started := hosted.race.StartedAt(); !started.IsZero() {
+		view.Started = true
+		view.StartedAt = &started
+	}
+	for _, standing := range hosted.race.Standings() {
+		view.Standings = append(view.Standings, standingView{Standing: standing, ElapsedSeconds: standing.Elapsed.Seconds()})
+	}
+	return view
+}
+
+func (s *Server) handleRaces(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.expireSessions()
+
+		s.mu.Lock()
+		races := make([]*hostedRace, 0, len(s.races))
+		for _, hosted := range s.races {
+			races = append(races, hosted)
+		}
+		s.mu.Unlock()
+
+		views := make([]raceView, 0, len(races))
+		for _, hosted := range races {
+			views = append(views, hosted.view())
+		}
+		writeJSON(w, http.StatusOK, views)
+	case http.MethodPost:
+		var req createRequest
+		if err := readJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if _, err := game.Difficulty(req.Difficulty); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		scenario, name, err := s.scenarioFor(req)
+		if errors.Is(err, errNotFound) {
+			writeError(w, http.StatusNotFound, fmt.Errorf("no scenario named %q", req.Scenario))
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		hosted := &hostedRace{id: newSessionID(), name: name, race: game.NewRace(scenario), created: time.Now()}
+		s.expireSessions()
+		s.mu.Lock()
+		s.races[hosted.id] = hosted
+		s.mu.Unlock()
+
+		log.Printf("Opened race %s (%s)", hosted.id, scenario.Theme)
+		writeJSON(w, http.StatusCreated, hosted.view())
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+func (s *Server) handleRace(w http.ResponseWriter, r *http.Request, hosted *hostedRace, rest []string) {
+	resource := ""
+	if len(rest) > 0 {
+		resource = rest[0]
+	}
+	if len(rest) > 1 {
+		writeError(w, http.StatusNotFound, errNotFound)
+		return
+	}
+
+	switch resource {
+	case "":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, http.MethodGet)
+			return
+		}
+		writeJSON(w, http.StatusOK, hosted.view())
+	case "racers":
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, http.MethodPost)
+			return
+		}
+		var req joinRequest
+		if err := readJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		racer, err := hosted.race.Join(req.Name)
+		if err != nil {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+
+		sess := s.startRaceSession(hosted, racer)
+		sess.mu.Lock()
+		defer sess.mu.Unlock()
+		writeJSON(w, http.StatusCreated, sess.view())
+	case "start":
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, http.MethodPost)
+			return
+		}
+		if err := hosted.race.Start(); err != nil {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+		log.Printf("Started race %s", hosted.id)
+		writeJSON(w, http.StatusOK, hosted.view())
+	default:
+		writeError(w, http.StatusNotFound, errNotFound)
+	}
+}
+
+// startRaceSession gives a racer a session to play their game through.
+func (s *Server) startRaceSession(hosted *hostedRace, racer *game.Racer) *session {
+	if s.llm != nil {
+		racer.Engine.SetDialogueVoice(s.llm)
+	}
+	if hosted.name != "" {
+		s.lib.RecordPlay(hosted.name)
+	}
+
+	sess := &session{
+		id:       newSessionID(),
+		name:     hosted.name,
+		player:   racer.Name,
+		engine:   racer.Engine,
+		race:     hosted,
+		racer:    racer,
+		lastUsed: time.Now(),
+	}
+
+	s.mu.Lock()
+	s.sessions[sess.id] = sess
+	s.mu.Unlock()
+
+	log.Printf("%s joined race %s with session %s", racer.Name, hosted.id, sess.id)
+	return sess
+}
+
+func (s *Server) race(id string) (*hostedRace, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hosted, ok := s.races[id]
+	return hosted, ok
+}
@@ -0,0 +1,491 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tahcohcat/go-escape-ai/game"
+	"github.com/tahcohcat/go-escape-ai/library"
+	"github.com/tahcohcat/go-escape-ai/llm"
+)
+
+// SessionTimeout is how long a session can sit idle before it is discarded.
+const SessionTimeout = 2 * time.Hour
+
+// Generator creates a scenario for a theme and difficulty and describes what
+// generated it. Offline asks for a procedural scenario built from the seed.
+type Generator func(theme string, seed int64, offline bool, level game.DifficultyLevel) (*game.Scenario, string, error)
+
+var errNotFound = errors.New("not found")
+
+// Server exposes game sessions over a JSON HTTP API. Each session is backed by
+// its own engine, so any number of games can run side by side.
+type Server struct {
+	lib      *library.Library
+	llm      *llm.Client
+	generate Generator
+
+	mu       sync.Mutex
+	sessions map[string]*session
+	races    map[string]*hostedRace
+}
+
+type session struct {
+	mu       sync.Mutex
+	id       string
+	name     string // library name, empty if the scenario isn't in the library
+	player   string // name on the leaderboard
+	engine   *game.Engine
+	lastUsed time.Time // guarded by Server.mu
+	recorded bool      // completion and the run have been written to the library
+
+	// race and racer are set when the session is a racer's game
+	race  *hostedRace
+	racer *game.Racer
+
+	// stopNarration cancels narration still streaming for the previous command
+	stopNarration context.CancelFunc
+}
+
+// New creates a server. The LLM client may be nil, in which case narration
+// falls back to canned text and NPCs use their authored lines.
+func New(lib *library.Library, llmClient *llm.Client, generate Generator) *Server {
+	return &Server{
+		lib:      lib,
+		llm:      llmClient,
+		generate: generate,
+		sessions: make(map[string]*session),
+		races:    make(map[string]*hostedRace),
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case len(parts) == 1 && parts[0] == "scenarios":
+		s.handleScenarios(w, r)
+	case len(parts) == 1 && parts[0] == "sessions":
+		s.handleSessions(w, r)
+	case len(parts) >= 2 && parts[0] == "sessions":
+		sess, ok := s.session(parts[1])
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Errorf("no session %q", parts[1]))
+			return
+		}
+		s.handleSession(w, r, sess, parts[2:])
+	case len(parts) == 1 && parts[0] == "races":
+		s.handleRaces(w, r)
+	case len(parts) >= 2 && parts[0] == "races":
+		hosted, ok := s.race(parts[1])
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Errorf("no race %q", parts[1]))
+			return
+		}
+		s.handleRace(w, r, hosted, parts[2:])
+	default:
+		writeError(w, http.StatusNotFound, errNotFound)
+	}
+}
+
+func (s *Server) handleScenarios(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	entries, err := s.lib.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if entries == nil {
+		entries = []library.Metadata{}
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+type createRequest struct {
+	Scenario   string `json:"scenario"` // library name
+	Theme      string `json:"theme"`
+	Seed       int64  `json:"seed"`
+	Difficulty string `json:"difficulty"` // for generated scenarios
+	Player     string `json:"player"`     // name on the leaderboard
+}
+
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.expireSessions()
+
+		s.mu.Lock()
+		ids := make([]string, 0, len(s.sessions))
+		for id := range s.sessions {
+			ids = append(ids, id)
+		}
+		s.mu.Unlock()
+
+		views := make([]sessionView, 0, len(ids))
+		for _, id := range ids {
+			if sess, ok := s.session(id); ok {
+				sess.mu.Lock()
+				views = append(views, sess.view())
+				sess.mu.Unlock()
+			}
+		}
+		writeJSON(w, http.StatusOK, views)
+	case http.MethodPost:
+		var req createRequest
+		if err := readJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if _, err := game.Difficulty(req.Difficulty); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		scenario, name, err := s.scenarioFor(req)
+		if errors.Is(err, errNotFound) {
+			writeError(w, http.StatusNotFound, fmt.Errorf("no scenario named %q", req.Scenario))
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		sess := s.startSession(scenario, name, req.Player)
+		sess.mu.Lock()
+		defer sess.mu.Unlock()
+		writeJSON(w, http.StatusCreated, sess.view())
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// scenarioFor loads the requested library scenario, or generates a new one and
+// adds it to the library.
+func (s *Server) scenarioFor(req createRequest) (*game.Scenario, string, error) {
+	if req.Scenario != "" {
+		if !s.lib.Exists(req.Scenario) {
+			return nil, "", errNotFound
+		}
+		scenario, err := s.lib.Load(req.Scenario)
+		return scenario, req.Scenario, err
+	}
+
+	seed := req.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	theme := strings.TrimSpace(req.Theme)
+	if theme == "" {
+		theme = game.Themes[int(uint64(seed)%uint64(len(game.Themes)))]
+	}
+
+	level, err := game.Difficulty(req.Difficulty)
+	if err != nil {
+		return nil, "", err
+	}
+	scenario, model, err := s.generate(theme, seed, req.Seed != 0, level)
+	if err != nil {
+		return nil, "", err
+	}
+
+	name, err := s.lib.Add(scenario, model)
+	if err != nil {
+		log.Printf("Could not save scenario to the library: %v", err)
+		return scenario, "", nil
+	}
+	return scenario, name, nil
+}
+
+func (s *Server) startSession(scenario *game.Scenario, name, player string) *session {
+	engine := game.NewEngine(scenario)
+	if s.llm != nil {
+		engine.SetDialogueVoice(s.llm)
+	}
+	if name != "" {
+		s.lib.RecordPlay(name)
+	}
+
+	sess := &session{id: newSessionID(), name: name, player: player, engine: engine, lastUsed: time.Now()}
+
+	s.expireSessions()
+	s.mu.Lock()
+	s.sessions[sess.id] = sess
+	s.mu.Unlock()
+
+	log.Printf("Started session %s (%s)", sess.id, scenario.Theme)
+	return sess
+}
+
+func (s *Server) session(id string) (*session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[id]
+	return sess, ok
+}
+
+func (s *Server) touch(sess *session) {
+	s.mu.Lock()
+	sess.lastUsed = time.Now()
+	s.mu.Unlock()
+}
+
+func (s *Server) expireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	racing := make(map[*hostedRace]bool)
+	for id, sess := range s.sessions {
+		if time.Since(sess.lastUsed) > SessionTimeout {
+			delete(s.sessions, id)
+		} else if sess.race != nil {
+			racing[sess.race] = true
+		}
+	}
+	for id, hosted := range s.races {
+		if !racing[hosted] && time.Since(hosted.created) > SessionTimeout {
+			delete(s.races, id)
+		}
+	}
+}
+
+type commandRequest struct {
+	Command string `json:"command"`
+	Narrate bool   `json:"narrate"`
+}
+
+type commandResponse struct {
+	*game.CommandResult
+	Error     string      `json:"error,omitempty"`
+	Narration string      `json:"narration,omitempty"`
+	State     sessionView `json:"state"`
+}
+
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request, sess *session, rest []string) {
+	resource := ""
+	if len(rest) > 0 {
+		resource = rest[0]
+	}
+	if len(rest) > 1 {
+		writeError(w, http.StatusNotFound, errNotFound)
+		return
+	}
+
+	if resource == "" && r.Method == http.MethodDelete {
+		s.mu.Lock()
+		delete(s.sessions, sess.id)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if resource == "stream" {
+		s.handleStream(w, r, sess)
+		return
+	}
+
+	if resource == "hints" && r.Method == http.MethodPost {
+		s.touch(sess)
+		sess.mu.Lock()
+		defer sess.mu.Unlock()
+
+		hints := engineHints(sess.engine)
+		if !hints.Enabled {
+			writeError(w, http.StatusForbidden, fmt.Errorf("there are no hints on %s difficulty", sess.engine.GetState().Scenario.DifficultyLevel().Name))
+			return
+		}
+		result, err := s.play(sess, "hint")
+		if errors.Is(err, game.ErrRaceNotStarted) {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		hints = engineHints(sess.engine)
+		hints.Hint = result.Text()
+		writeJSON(w, http.StatusOK, hints)
+		return
+	}
+
+	if resource == "commands" {
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, http.MethodPost)
+			return
+		}
+		var req commandRequest
+		if err := readJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if strings.TrimSpace(req.Command) == "" {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("command is required"))
+			return
+		}
+
+		s.touch(sess)
+		sess.mu.Lock()
+		defer sess.mu.Unlock()
+
+		// Errors such as unknown commands are part of playing, so they're
+		// reported alongside the result rather than as a failed request
+		result, err := s.play(sess, req.Command)
+		if errors.Is(err, game.ErrRaceNotStarted) {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+
+		response := commandResponse{CommandResult: result}
+		if err != nil {
+			response.Error = err.Error()
+		}
+		if req.Narrate {
+			response.Narration = s.narrate(sess.engine, req.Command)
+		}
+		response.State = sess.view()
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		if resource == "" {
+			methodNotAllowed(w, http.MethodGet, http.MethodDelete)
+		} else if resource == "hints" {
+			methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		} else {
+			methodNotAllowed(w, http.MethodGet)
+		}
+		return
+	}
+
+	s.touch(sess)
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	switch resource {
+	case "":
+		writeJSON(w, http.StatusOK, sess.view())
+	case "inventory":
+		writeJSON(w, http.StatusOK, inventoryItems(sess.engine))
+	case "stats":
+		writeJSON(w, http.StatusOK, engineStats(sess.engine))
+	case "hints":
+		writeJSON(w, http.StatusOK, engineHints(sess.engine))
+	case "leaderboard":
+		board, err := s.lib.Leaderboard(sess.engine.GetState().Scenario)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		board.Runs = board.Ranked()
+		writeJSON(w, http.StatusOK, board)
+	default:
+		writeError(w, http.StatusNotFound, errNotFound)
+	}
+}
+
+// play runs a command in the session, which must be locked.
+func (s *Server) play(sess *session, command string) (*game.CommandResult, error) {
+	if sess.stopNarration != nil {
+		sess.stopNarration()
+		sess.stopNarration = nil
+	}
+
+	var result *game.CommandResult
+	var err error
+	if sess.racer != nil {
+		result, err = sess.race.race.Play(sess.racer, command)
+	} else {
+		result, err = sess.engine.ProcessCommand(command)
+	}
+	if sess.engine.IsGameWon() && !sess.recorded {
+		sess.recorded = true
+		if sess.name != "" {
+			s.lib.RecordCompletion(sess.name, sess.engine.Elapsed())
+		}
+		run := library.NewRun(sess.player, sess.engine.Stats())
+		if _, _, err := s.lib.RecordRun(sess.engine.GetState().Scenario, run); err != nil {
+			log.Printf("Could not record run for session %s: %v", sess.id, err)
+		}
+	}
+	return result, err
+}
+
+func (s *Server) narrate(engine *game.Engine, input string) string {
+	ctx, ok := narrationContext(engine, input)
+	if !ok {
+		return ""
+	}
+
+	narration, err := s.llm.GenerateNarration(ctx)
+	if err != nil {
+		return ""
+	}
+	return narration
+}
+
+// narrationContext describes the game for the narrator using a snapshot of the
+// state, so narration can carry on while the session plays further commands.
+func narrationContext(engine *game.Engine, input string) (llm.NarrationContext, bool) {
+	state := engine.Snapshot()
+	room, err := state.Scenario.GetRoom(state.CurrentRoom)
+	if err != nil {
+		return llm.NarrationContext{}, false
+	}
+
+	return llm.NarrationContext{
+		CurrentRoom:      room,
+		LastAction:       state.LastAction,
+		LastResult:       state.LastResult,
+		Inventory:        state.Inventory,
+		GameState:        state,
+		PlayerInput:      input,
+		ProgressiveHints: engine.GetProgressiveHints(),
+	}, true
+}
+
+func newSessionID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+func readJSON(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil && err != io.EOF {
+		return fmt.Errorf("invalid request body: %v", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed"))
+}
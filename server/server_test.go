@@ -0,0 +1,257 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tahcohcat/go-escape-ai/game"
+	"github.com/tahcohcat/go-escape-ai/library"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	lib, err := library.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	generate := func(theme string, seed int64, offline bool, level game.DifficultyLevel) (*game.Scenario, string, error) {
+		return game.GenerateScenario(theme, seed, level), "procedural", nil
+	}
+	return New(lib, nil, generate)
+}
+
+// request sends a JSON request, checks the status and decodes the response into out.
+func request(t *testing.T, s *Server, method, path string, body interface{}, status int, out interface{}) {
+	t.Helper()
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(method, path, &reader))
+	if recorder.Code != status {
+		t.Fatalf("%s %s: status %d, want %d: %s", method, path, recorder.Code, status, recorder.Body)
+	}
+	if out != nil {
+		if err := json.Unmarshal(recorder.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+	}
+}
+
+func startTestSession(t *testing.T, s *Server, difficulty string) string {
+	t.Helper()
+	var view sessionView
+	request(t, s, http.MethodPost, "/sessions", createRequest{Theme: "Pirate Ship", Seed: 7, Difficulty: difficulty}, http.StatusCreated, &view)
+	return view.ID
+}
+
+func TestHintsAreCharged(t *testing.T) {
+	s := newTestServer(t)
+	id := startTestSession(t, s, game.DifficultyNormal)
+
+	var hints hintsView
+	request(t, s, http.MethodGet, "/sessions/"+id+"/hints", nil, http.StatusOK, &hints)
+	if !hints.Enabled || len(hints.Given) != 0 {
+		t.Fatalf("new session hints = %+v, want enabled with none given", hints)
+	}
+
+	request(t, s, http.MethodPost, "/sessions/"+id+"/hints", nil, http.StatusOK, &hints)
+	if hints.Hint == "" || len(hints.Given) != 1 {
+		t.Fatalf("asking for a hint gave %+v, want one hint", hints)
+	}
+
+	var stats statsView
+	request(t, s, http.MethodGet, "/sessions/"+id+"/stats", nil, http.StatusOK, &stats)
+	if stats.HintsUsed != 1 || stats.HintsShown != 1 || stats.ScoreBreakdown.Hints >= 0 {
+		t.Errorf("stats after one hint = %+v", stats)
+	}
+}
+
+func TestHintsRefusedOnExpert(t *testing.T) {
+	s := newTestServer(t)
+	id := startTestSession(t, s, game.DifficultyExpert)
+
+	request(t, s, http.MethodPost, "/sessions/"+id+"/hints", nil, http.StatusForbidden, nil)
+
+	var hints hintsView
+	request(t, s, http.MethodGet, "/sessions/"+id+"/hints", nil, http.StatusOK, &hints)
+	if hints.Enabled || len(hints.Given) != 0 {
+		t.Errorf("expert hints = %+v, want disabled with none given", hints)
+	}
+	var stats statsView
+	request(t, s, http.MethodGet, "/sessions/"+id+"/stats", nil, http.StatusOK, &stats)
+	if stats.HintsUsed != 0 {
+		t.Errorf("hints used = %d, want 0", stats.HintsUsed)
+	}
+}
+
+func TestStreamNarratesOnlyWhenAsked(t *testing.T) {
+	s := newTestServer(t)
+	id := startTestSession(t, s, game.DifficultyNormal)
+
+	stream := func(query string) string {
+		recorder := httptest.NewRecorder()
+		s.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/sessions/"+id+"/stream?command=look"+query, nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("stream%s: status %d: %s", query, recorder.Code, recorder.Body)
+		}
+		return recorder.Body.String()
+	}
+
+	if body := stream(""); strings.Contains(body, "event: narration") || !strings.Contains(body, "event: done") {
+		t.Errorf("stream without narrate:\n%s", body)
+	}
+	if body := stream("&narrate=true"); !strings.Contains(body, "event: narration") || !strings.Contains(body, "event: done") {
+		t.Errorf("stream with narrate:\n%s", body)
+	}
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	s := newTestServer(t)
+
+	request(t, s, http.MethodPost, "/sessions", createRequest{Difficulty: "impossible"}, http.StatusBadRequest, nil)
+	request(t, s, http.MethodPost, "/sessions", createRequest{Scenario: "missing"}, http.StatusNotFound, nil)
+
+	var created sessionView
+	request(t, s, http.MethodPost, "/sessions", createRequest{Theme: "Pirate Ship", Seed: 7, Player: "ada"}, http.StatusCreated, &created)
+	if created.Scenario == "" || created.Room == nil || created.GameWon {
+		t.Fatalf("new session = %+v, want a library scenario in its first room", created)
+	}
+
+	var sessions []sessionView
+	request(t, s, http.MethodGet, "/sessions", nil, http.StatusOK, &sessions)
+	if len(sessions) != 1 || sessions[0].ID != created.ID {
+		t.Errorf("sessions = %+v, want just the new one", sessions)
+	}
+
+	path := "/sessions/" + created.ID
+	request(t, s, http.MethodPost, path+"/commands", commandRequest{Command: "  "}, http.StatusBadRequest, nil)
+	request(t, s, http.MethodGet, path+"/commands", nil, http.StatusMethodNotAllowed, nil)
+	request(t, s, http.MethodGet, path+"/nowhere", nil, http.StatusNotFound, nil)
+
+	// Commands the game doesn't understand are part of playing, not failed requests
+	var response commandResponse
+	request(t, s, http.MethodPost, path+"/commands", commandRequest{Command: "xyzzy"}, http.StatusOK, &response)
+	if response.CommandResult == nil || response.Text() == "" {
+		t.Errorf("unknown command response = %+v, want the game's reply", response)
+	}
+
+	// A second game of the same library scenario starts fresh
+	var second sessionView
+	request(t, s, http.MethodPost, "/sessions", createRequest{Scenario: created.Scenario}, http.StatusCreated, &second)
+	if second.ID == created.ID || second.Theme != created.Theme {
+		t.Errorf("second session = %+v, want a new session of %s", second, created.Theme)
+	}
+
+	request(t, s, http.MethodDelete, path, nil, http.StatusNoContent, nil)
+	request(t, s, http.MethodGet, path, nil, http.StatusNotFound, nil)
+}
+
+func TestWinningRecordsTheRun(t *testing.T) {
+	s := newTestServer(t)
+
+	var created sessionView
+	request(t, s, http.MethodPost, "/sessions", createRequest{Theme: "Pirate Ship", Seed: 7, Player: "ada"}, http.StatusCreated, &created)
+	scenario, err := s.lib.Load(created.Scenario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, err := game.Solve(scenario, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var response commandResponse
+	for _, step := range steps {
+		request(t, s, http.MethodPost, "/sessions/"+created.ID+"/commands", commandRequest{Command: step.Command}, http.StatusOK, &response)
+	}
+	if !response.State.GameWon {
+		t.Fatalf("after the solution, state = %+v, want the game won", response.State)
+	}
+
+	var board struct {
+		Runs []struct {
+			Player string `json:"player"`
+			Moves  int    `json:"moves"`
+		} `json:"runs"`
+	}
+	request(t, s, http.MethodGet, "/sessions/"+created.ID+"/leaderboard", nil, http.StatusOK, &board)
+	if len(board.Runs) != 1 || board.Runs[0].Player != "ada" || board.Runs[0].Moves != len(steps) {
+		t.Errorf("leaderboard = %+v, want ada's run of %d moves", board, len(steps))
+	}
+	if meta, err := s.lib.Metadata(created.Scenario); err != nil || !meta.Completed || meta.Plays != 1 {
+		t.Errorf("metadata = %+v (%v), want one completed play", meta, err)
+	}
+
+	// Playing on after the escape doesn't record it again
+	request(t, s, http.MethodPost, "/sessions/"+created.ID+"/commands", commandRequest{Command: "look"}, http.StatusOK, nil)
+	request(t, s, http.MethodGet, "/sessions/"+created.ID+"/leaderboard", nil, http.StatusOK, &board)
+	if len(board.Runs) != 1 {
+		t.Errorf("leaderboard has %d runs after playing on, want 1", len(board.Runs))
+	}
+}
+
+func TestRaceHandlers(t *testing.T) {
+	s := newTestServer(t)
+
+	var race raceView
+	request(t, s, http.MethodPost, "/races", createRequest{Theme: "Pirate Ship", Seed: 7}, http.StatusCreated, &race)
+	if race.Started || len(race.Standings) != 0 {
+		t.Fatalf("new race = %+v, want nobody racing yet", race)
+	}
+	path := "/races/" + race.ID
+
+	request(t, s, http.MethodPost, path+"/start", nil, http.StatusConflict, nil)
+
+	var ada, bo sessionView
+	request(t, s, http.MethodPost, path+"/racers", joinRequest{Name: "ada"}, http.StatusCreated, &ada)
+	request(t, s, http.MethodPost, path+"/racers", joinRequest{Name: "bo"}, http.StatusCreated, &bo)
+	request(t, s, http.MethodPost, path+"/racers", joinRequest{Name: "ada"}, http.StatusConflict, nil)
+	if ada.Race != race.ID {
+		t.Errorf("racer session race = %q, want %q", ada.Race, race.ID)
+	}
+
+	// Nobody gets a head start
+	request(t, s, http.MethodPost, "/sessions/"+ada.ID+"/commands", commandRequest{Command: "look"}, http.StatusConflict, nil)
+
+	request(t, s, http.MethodPost, path+"/start", nil, http.StatusOK, &race)
+	if !race.Started {
+		t.Fatalf("race after start = %+v", race)
+	}
+	request(t, s, http.MethodPost, path+"/start", nil, http.StatusConflict, nil)
+
+	scenario, err := s.lib.Load(race.Scenario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, err := game.Solve(scenario, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range steps {
+		request(t, s, http.MethodPost, "/sessions/"+bo.ID+"/commands", commandRequest{Command: step.Command}, http.StatusOK, nil)
+	}
+	request(t, s, http.MethodPost, "/sessions/"+ada.ID+"/commands", commandRequest{Command: "look"}, http.StatusOK, nil)
+
+	request(t, s, http.MethodGet, path, nil, http.StatusOK, &race)
+	if len(race.Standings) != 2 || race.Standings[0].Name != "bo" || !race.Standings[0].Finished || race.Standings[1].Finished {
+		t.Errorf("standings = %+v, want bo first and finished, ada still playing", race.Standings)
+	}
+	if race.Finished {
+		t.Error("race finished with ada still playing")
+	}
+
+	var races []raceView
+	request(t, s, http.MethodGet, "/races", nil, http.StatusOK, &races)
+	if len(races) != 1 {
+		t.Errorf("races = %+v, want one", races)
+	}
+	request(t, s, http.MethodGet, "/races/missing", nil, http.StatusNotFound, nil)
+}
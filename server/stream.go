@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tahcohcat/go-escape-ai/game"
+)
+
+// handleStream plays a command and answers with a stream of server-sent
+// events: an "event" for each engine event, then "state", then a "narration"
+// event per chunk of narration as the provider produces it if narration was
+// asked for, and finally "done". Narration still streaming when the next
+// command arrives for the session is cut short with "cancelled". The command
+// comes from the JSON body of a POST or, for EventSource clients, the command
+// and narrate query parameters of a GET.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request, sess *session) {
+	var req commandRequest
+	switch r.Method {
+	case http.MethodGet:
+		req.Command = r.URL.Query().Get("command")
+		req.Narrate, _ = strconv.ParseBool(r.URL.Query().Get("narrate"))
+	case http.MethodPost:
+		if err := readJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		return
+	}
+	if strings.TrimSpace(req.Command) == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("command is required"))
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+
+	s.touch(sess)
+	sess.mu.Lock()
+	result, err := s.play(sess, req.Command)
+	if errors.Is(err, game.ErrRaceNotStarted) {
+		sess.mu.Unlock()
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	view := sess.view()
+	narration, narrate := narrationContext(sess.engine, req.Command)
+	narrate = narrate && req.Narrate
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	sess.stopNarration = cancel
+	sess.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event string, data interface{}) {
+		payload, _ := json.Marshal(data)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+		flusher.Flush()
+	}
+
+	for _, event := range result.Events {
+		send("event", event)
+	}
+	if err != nil {
+		send("error", map[string]string{"error": err.Error()})
+	}
+	send("state", view)
+
+	if !narrate {
+		send("done", map[string]string{})
+		return
+	}
+
+	var text strings.Builder
+	err = s.llm.StreamNarration(ctx, narration, func(token string) {
+		text.WriteString(token)
+		send("narration", map[string]string{"text": token})
+	})
+	switch {
+	case ctx.Err() != nil:
+		// The client is gone or has moved on to its next command
+		if r.Context().Err() == nil {
+			send("cancelled", map[string]string{})
+		}
+	case err != nil:
+		send("error", map[string]string{"error": err.Error()})
+	default:
+		send("done", map[string]string{"narration": text.String()})
+	}
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"github.com/tahcohcat/go-escape-ai/game"
+)
+
+type sessionView struct {
+	ID        string     `json:"id"`
+	Scenario  string     `json:"scenario,omitempty"` // library name
+	Race      string     `json:"race,omitempty"`
+	Theme     string     `json:"theme"`
+	Setting   string     `json:"setting"`
+	BackStory string     `json:"backstory"`
+	Room      *roomView  `json:"room,omitempty"`
+	Inventory []itemView `json:"inventory"`
+	Timer     string     `json:"timer,omitempty"`
+	GameWon   bool       `json:"game_won"`
+	GameLost  bool       `json:"game_lost"`
+	Ending    string     `json:"ending,omitempty"`
+}
+
+type roomView struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Items       []itemView   `json:"items"`
+	Exits       []exitView   `json:"exits"`
+	Puzzles     []puzzleView `json:"puzzles"`
+	NPCs        []npcView    `json:"npcs"`
+}
+
+type itemView struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type exitView struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Locked bool   `json:"locked"`
+}
+
+type puzzleView struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Solved      bool   `json:"solved"`
+}
+
+type npcView struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type statsView struct {
+	game.GameStats
+	ScoreBreakdown game.Score `json:"score_breakdown"`
+	ElapsedSeconds float64    `json:"elapsed_seconds"`
+	TimerRemaining *int       `json:"timer_remaining,omitempty"` // seconds or moves, depending on the timer mode
+}
+
+type hintsView struct {
+	Enabled bool     `json:"enabled"` // false on difficulties without hints
+	Given   []string `json:"given"`
+	Hint    string   `json:"hint,omitempty"` // what asking for a hint just said
+}
+
+func (sess *session) view() sessionView {
+	engine := sess.engine
+	state := engine.GetState()
+	scenario := state.Scenario
+
+	view := sessionView{
+		ID:        sess.id,
+		Scenario:  sess.name,
+		Theme:     scenario.Theme,
+		Setting:   scenario.Setting,
+		BackStory: scenario.BackStory,
+		Inventory: inventoryItems(engine),
+		Timer:     engine.TimerStatus(),
+		GameWon:   engine.IsGameWon(),
+		GameLost:  engine.IsGameLost(),
+		Ending:    engine.GetEndingMessage(),
+	}
+	if sess.race != nil {
+		view.Race = sess.race.id
+	}
+
+	room, err := engine.GetCurrentRoom()
+	if err != nil {
+		return view
+	}
+	view.Room = &roomView{
+		ID:          room.ID,
+		Name:        room.Name,
+		Description: room.Description,
+		Items:       []itemView{},
+		Exits:       []exitView{},
+		Puzzles:     []puzzleView{},
+		NPCs:        []npcView{},
+	}
+
+	for _, itemID := range room.Items {
+		item, err := scenario.GetItem(itemID)
+		if err != nil || state.IsItemHidden(itemID) || engine.HasItem(itemID) {
+			continue
+		}
+		view.Room.Items = append(view.Room.Items, itemView{ID: item.ID, Name: item.Name, Description: item.Description})
+	}
+	for _, exitID := range room.Exits {
+		exit := exitView{ID: exitID, Name: exitID, Locked: state.IsRoomLocked(exitID)}
+		if other, err := scenario.GetRoom(exitID); err == nil {
+			exit.Name = other.Name
+		}
+		view.Room.Exits = append(view.Room.Exits, exit)
+	}
+	for _, puzzleID := range room.Puzzles {
+		if puzzle, err := scenario.GetPuzzle(puzzleID); err == nil {
+			view.Room.Puzzles = append(view.Room.Puzzles, puzzleView{
+				ID:          puzzle.ID,
+				Name:        puzzle.Name,
+				Description: puzzle.Description,
+				Solved:      engine.IsPuzzleSolved(puzzleID),
+			})
+		}
+	}
+	for _, npcID := range room.NPCs {
+		if npc, err := scenario.GetNPC(npcID); err == nil {
+			view.Room.NPCs = append(view.Room.NPCs, npcView{ID: npc.ID, Name: npc.Name, Description: npc.Description})
+		}
+	}
+
+	return view
+}
+
+func inventoryItems(engine *game.Engine) []itemView {
+	state := engine.GetState()
+	items := []itemView{}
+	for _, itemID := range state.Inventory {
+		if item, err := state.Scenario.GetItem(itemID); err == nil {
+			items = append(items, itemView{ID: item.ID, Name: item.Name, Description: item.Description})
+		}
+	}
+	return items
+}
+
+func engineStats(engine *game.Engine) statsView {
+	stats := statsView{GameStats: engine.Stats(), ScoreBreakdown: engine.Score()}
+	stats.ElapsedSeconds = stats.Elapsed.Seconds()
+	if engine.HasTimer() {
+		remaining := engine.TimerRemaining()
+		stats.TimerRemaining = &remaining
+	}
+	return stats
+}
+
+// engineHints lists the hints already given. New ones are only had by asking,
+// which is charged for like the hint command.
+func engineHints(engine *game.Engine) hintsView {
+	hints := hintsView{Enabled: engine.GetState().Scenario.DifficultyLevel().HintCommand, Given: []string{}}
+	hints.Given = append(hints.Given, engine.HintsGiven()...)
+	return hints
+}